@@ -0,0 +1,63 @@
+package main
+
+/// @file advise.go
+/// @brief Heuristic warnings about parameter sets with trivial dynamics.
+/// @details `-advise` prints these before the run starts. They are rules of
+/// thumb, not errors: a flagged configuration still runs.
+
+import (
+	"fmt"
+	"io"
+)
+
+// / @brief Check a set of settings for likely degenerate runs.
+// / @details A pure function of `c`; `printAdvice()` passes the current
+// / settings.
+// / @return []string One warning with a suggestion per problem found.
+func adviseConfig(c Config) []string {
+	var warn []string
+	add := func(format string, args ...interface{}) {
+		warn = append(warn, fmt.Sprintf(format, args...))
+	}
+
+	if c.Fish == 0 && c.Sharks > 0 {
+		add("there are no fish, so all sharks will starve within %d ticks; add fish with a positive count", c.SharkStarve+1)
+	}
+	if c.Sharks == 0 && c.Fish > 0 {
+		add("there are no sharks, so the fish will simply fill the grid; add a few sharks")
+	}
+	if c.FishBreed == 0 {
+		add("fishBreed=0 makes fish breed every tick and fill the grid almost instantly; try 3 or more")
+	}
+	if c.FishStarve > 0 && c.FishStarve < c.FishBreed {
+		add("fishStarve=%d is below fishBreed=%d, so every fish starves before it can breed; use fishStarve >= fishBreed or 0",
+			c.FishStarve, c.FishBreed)
+	}
+	if c.Sharks > 0 && c.SharkStarve <= 1 {
+		add("sharkStarve=%d forces sharks to eat almost every tick, so they usually die out at once; try 3 or more", c.SharkStarve)
+	}
+	if c.Sharks > 0 && c.SharkBreed <= c.FishBreed {
+		add("sharkBreed=%d is not larger than fishBreed=%d, so sharks multiply as fast as their prey and tend to eat it all; try sharkBreed about 2-3x fishBreed",
+			c.SharkBreed, c.FishBreed)
+	}
+	if c.Sharks > 0 && c.SharkStarve > 3*c.SharkBreed {
+		add("sharkStarve=%d is far larger than sharkBreed=%d, so sharks breed many times without food and overrun the fish; keep sharkStarve near or below sharkBreed",
+			c.SharkStarve, c.SharkBreed)
+	}
+	if c.Fish+c.Sharks > c.Width*c.Height*9/10 {
+		add("%d creatures occupy over 90%% of the %d cells, leaving hardly any room to move", c.Fish+c.Sharks, c.Width*c.Height)
+	}
+	return warn
+}
+
+// / @brief Print the advice to `w`.
+func printAdvice(w io.Writer) {
+	warn := adviseConfig(currentConfig())
+	if len(warn) == 0 {
+		fmt.Fprintln(w, "advice: no obvious problems with these settings")
+		return
+	}
+	for _, s := range warn {
+		fmt.Fprintln(w, "advice: "+s)
+	}
+}
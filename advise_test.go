@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// / @brief Each rule fires on the setting it is about, and only then.
+func TestAdviseConfig(t *testing.T) {
+	if warn := adviseConfig(defaultConfig); len(warn) != 0 {
+		t.Fatalf("defaults give advice %q", warn)
+	}
+	for _, tc := range []struct {
+		name string
+		edit func(c *Config)
+		want string // a word of the single expected warning
+	}{
+		{"no fish", func(c *Config) { c.Fish = 0 }, "no fish"},
+		{"no sharks", func(c *Config) { c.Sharks = 0 }, "no sharks"},
+		{"fish breed every tick", func(c *Config) { c.FishBreed, c.SharkBreed = 0, 10 }, "fishBreed=0"},
+		{"fish starve before breeding", func(c *Config) { c.FishStarve = c.FishBreed - 1 }, "fishStarve="},
+		{"sharks starve at once", func(c *Config) { c.SharkStarve = 1 }, "sharkStarve=1"},
+		{"sharks breed as fast as fish", func(c *Config) { c.SharkBreed = c.FishBreed }, "not larger"},
+		{"sharks never starve", func(c *Config) { c.SharkStarve = 3*c.SharkBreed + 1 }, "far larger"},
+		{"crowded", func(c *Config) { c.Width, c.Height, c.Fish, c.Sharks = 10, 10, 80, 11 }, "90%"},
+	} {
+		c := defaultConfig
+		tc.edit(&c)
+		warn := adviseConfig(c)
+		if len(warn) != 1 || !strings.Contains(warn[0], tc.want) {
+			t.Errorf("%s: got %q, want one warning about %q", tc.name, warn, tc.want)
+		}
+	}
+}
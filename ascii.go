@@ -0,0 +1,215 @@
+package main
+
+/// @file ascii.go
+/// @brief Text renderer for terminals without a GUI.
+/// @details `-render ascii` runs without a window (as with `-headless`)
+/// and redraws the grid in the terminal with ANSI cursor control, at most
+/// `-ascii-fps` times per second: space for empty, `.` for fish and `#` for
+/// sharks. Grids larger than the terminal are downsampled: each character
+/// stands for a block of cells and shows whichever creature is most common
+/// in it (sharks on a tie). The terminal size comes from `-ascii-cols` /
+/// `-ascii-rows`, else from $COLUMNS / $LINES, else 80x40.
+///
+/// `-ascii-glyphs` packs more of the grid into each character, for this
+/// and `-render tui`: `half` uses the Unicode half blocks for two cells
+/// stacked in one character and `braille` the Braille patterns for 2x4
+/// cells, so a 400x400 grid fits 200x100 characters without losing any
+/// cell. The downsampling then works on those dots instead of characters.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+var asciiCols int = 0
+var asciiRows int = 0
+var asciiFPS int = 10
+var asciiGlyphs string = "plain"
+
+var asciiLast time.Time
+
+// / @brief Terminal size to render for; one row is kept for the status line.
+func asciiSize() (cols, rows int) {
+	cols, rows = asciiCols, asciiRows
+	if cols <= 0 {
+		cols = 80
+		if v, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && v > 0 {
+			cols = v
+		}
+	}
+	if rows <= 0 {
+		rows = 40
+		if v, err := strconv.Atoi(os.Getenv("LINES")); err == nil && v > 1 {
+			rows = v - 1
+		}
+	}
+	return cols, rows
+}
+
+// / @brief Downsample the grid to at most `cols` x `rows` blocks.
+// / @return blocks The state shown for each block, row by row: whichever
+// / creature is most common in it (sharks on a tie), else 0.
+// / @return outW, outH Number of blocks per row and of rows.
+func asciiBlocks(cols, rows int) (blocks []uint8, outW, outH int) {
+	bw := (wator.Width + cols - 1) / cols
+	bh := (wator.Height + rows - 1) / rows
+	outW = (wator.Width + bw - 1) / bw
+	outH = (wator.Height + bh - 1) / bh
+
+	// fish and shark counts per block
+	counts := make([][2]int, outW*outH)
+	wator.ForEachCell(func(x, y int, state uint8) {
+		if state != 0 {
+			counts[(y/bh)*outW+x/bw][state-1]++
+		}
+	})
+
+	blocks = make([]uint8, outW*outH)
+	for i, c := range counts {
+		switch {
+		case c[1] > 0 && c[1] >= c[0]:
+			blocks[i] = 2
+		case c[0] > 0:
+			blocks[i] = 1
+		}
+	}
+	return blocks, outW, outH
+}
+
+// / @brief Terminal cells per character, as width and height, of each
+// / `-ascii-glyphs` setting.
+var glyphSizes = map[string][2]int{
+	"plain":   {1, 1},
+	"half":    {1, 2},
+	"braille": {2, 4},
+}
+
+// / @brief The grid downsampled to the dots of `asciiGlyphs` characters.
+type glyphGrid struct {
+	dots       []uint8 // state of each dot, row by row, see `asciiBlocks()`
+	dotW, dotH int
+	w, h       int // size in characters
+	gw, gh     int // dots per character
+}
+
+// / @brief Downsample the grid to fit `cols` x `rows` characters.
+func newGlyphGrid(cols, rows int) glyphGrid {
+	size := glyphSizes[asciiGlyphs]
+	g := glyphGrid{gw: size[0], gh: size[1]}
+	g.dots, g.dotW, g.dotH = asciiBlocks(cols*g.gw, rows*g.gh)
+	g.w = (g.dotW + g.gw - 1) / g.gw
+	g.h = (g.dotH + g.gh - 1) / g.gh
+	return g
+}
+
+// / @brief State of dot `(x, y)`; 0 past the edge of the last characters.
+func (g glyphGrid) at(x, y int) uint8 {
+	if x >= g.dotW || y >= g.dotH {
+		return 0
+	}
+	return g.dots[y*g.dotW+x]
+}
+
+// / @brief Braille character of character cell `(cx, cy)`, with a dot for
+// / every creature, and the most common creature among them (sharks on a
+// / tie), else 0.
+func (g glyphGrid) braille(cx, cy int) (r rune, state uint8) {
+	// bit of each dot in U+2800..U+28FF, by column and row
+	bits := [2][4]uint{{0, 1, 2, 6}, {3, 4, 5, 7}}
+	var mask rune
+	var n [3]int
+	for dx := 0; dx < 2; dx++ {
+		for dy := 0; dy < 4; dy++ {
+			s := g.at(2*cx+dx, 4*cy+dy)
+			if s != 0 {
+				mask |= 1 << bits[dx][dy]
+			}
+			n[s]++
+		}
+	}
+	switch {
+	case mask == 0:
+		return ' ', 0
+	case n[2] >= n[1]:
+		state = 2
+	default:
+		state = 1
+	}
+	return 0x2800 + mask, state
+}
+
+// / @brief Draw the grid as at most `cols` x `rows` characters.
+// / @details Without colors `half` and `braille` only show where creatures
+// / are, not which; `-render tui` colors them.
+// / @return string The lines, each terminated by a newline.
+func renderASCII(cols, rows int) string {
+	g := newGlyphGrid(cols, rows)
+	var b strings.Builder
+	for cy := 0; cy < g.h; cy++ {
+		for cx := 0; cx < g.w; cx++ {
+			switch asciiGlyphs {
+			case "half":
+				top, bottom := g.at(cx, 2*cy) != 0, g.at(cx, 2*cy+1) != 0
+				switch {
+				case top && bottom:
+					b.WriteRune('\u2588')
+				case top:
+					b.WriteRune('\u2580')
+				case bottom:
+					b.WriteRune('\u2584')
+				default:
+					b.WriteByte(' ')
+				}
+			case "braille":
+				r, _ := g.braille(cx, cy)
+				b.WriteRune(r)
+			default:
+				b.WriteByte(" .#"[g.at(cx, cy)])
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// / @brief Redraw the terminal if the frame rate allows; called after each tick.
+// / @param force Draw regardless of the frame rate (first and last frame).
+func drawASCII(w io.Writer, force bool) {
+	if !force && asciiFPS > 0 && time.Since(asciiLast) < time.Second/time.Duration(asciiFPS) {
+		return
+	}
+	asciiLast = time.Now()
+	cols, rows := asciiSize()
+	bw := bufio.NewWriter(w)
+	bw.WriteString("\x1b[H")
+	bw.WriteString(renderASCII(cols, rows))
+	st := wator.CollectStats()
+	fmt.Fprintf(bw, "tick %d: %d fish, %d sharks\x1b[K\n", st.Tick, st.Fish, st.Sharks)
+	bw.Flush()
+}
+
+// / @brief Run up to `ticks` ticks, drawing them in the terminal.
+// / @return error The first error from `wator.Update()`.
+func runASCII(w io.Writer, ticks int) error {
+	fmt.Fprint(w, "\x1b[2J")
+	drawASCII(w, true)
+	err := headlessLoop(ticks, func() (bool, error) {
+		if wator.Extinct {
+			return true, nil
+		}
+		err := wator.Update()
+		recordTick()
+		wator.Extinct = wator.CheckExtinction()
+		drawASCII(w, false)
+		return wator.Extinct, err
+	})
+	drawASCII(w, true)
+	return err
+}
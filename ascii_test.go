@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Each glyph set draws a small world as expected, at full size and
+// / downsampled, with sharks winning a tied block, and a frame adds the
+// / cursor reset and the status line.
+func TestRenderASCII(t *testing.T) {
+	defer func(old string) { asciiGlyphs = old }(asciiGlyphs)
+	w, err := wator.NewWorld(wator.WithSize(6, 4), wator.WithFish(0), wator.WithSharks(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Load()
+	defer w.Unload()
+	layout := "f.S...\nff..S.\n..S...\n.f.fSS\n"
+	if err := wator.ReadText(strings.NewReader(layout)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		glyphs     string
+		cols, rows int
+		want       string
+	}{
+		{"plain", 6, 4, ". #   \n..  # \n  #   \n . .##\n"},
+		// 2x2 blocks; the block at (1,1) holds one fish and one shark
+		{"plain", 3, 2, ".##\n.##\n"},
+		{"half", 6, 2, "\u2588\u2584\u2580 \u2584 \n \u2584\u2580\u2584\u2584\u2584\n"},
+		{"braille", 3, 1, "\u2893\u2885\u28c2\n"},
+	} {
+		asciiGlyphs = tc.glyphs
+		if got := renderASCII(tc.cols, tc.rows); got != tc.want {
+			t.Errorf("%s in %dx%d:\n%q\nwant\n%q", tc.glyphs, tc.cols, tc.rows, got, tc.want)
+		}
+	}
+
+	asciiGlyphs = "plain"
+	defer func(c, r int) { asciiCols, asciiRows = c, r }(asciiCols, asciiRows)
+	asciiCols, asciiRows = 6, 4
+	var b strings.Builder
+	drawASCII(&b, true)
+	want := "\x1b[H" + ". #   \n..  # \n  #   \n . .##\n" + "tick 0: 5 fish, 5 sharks\x1b[K\n"
+	if b.String() != want {
+		t.Errorf("frame %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+/// @file bench.go
+/// @brief Benchmark mode: time `wator.Update()` for several thread counts.
+/// @details Results are CSV. Without `-bench-out` they go to stdout as
+/// before; with it they are written to the file (optionally appended with
+/// `-bench-append`, so results from several machines can be collected in
+/// one file) and a short summary is echoed to stdout.
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief CSV header of the benchmark results.
+var benchHeader = []string{
+	"threads", "steps", "time_seconds", "ticks_per_second",
+	"alloc_bytes", "alloc_bytes_per_tick", "mallocs", "gc_count",
+	"go_version", "gomaxprocs", "num_cpu",
+}
+
+// / @brief Measurements of one benchmark run.
+type benchResult struct {
+	elapsed    time.Duration
+	allocBytes uint64 // bytes allocated during the timed region
+	mallocs    uint64 // heap objects allocated during the timed region
+	gcCount    uint32 // garbage collections during the timed region
+}
+
+// / @brief Seed of every benchmark run; `-seed` overrides it.
+var benchSeed int64 = 42
+
+// / @brief Run a single benchmark of the simulation for `steps` ticks.
+// / @details A GC is forced right before the timed region so the memory
+// / figures only cover the `wator.Update()` calls, not world setup or garbage
+// / left by a previous run.
+// / @param steps Number of simulation ticks to execute.
+// / @param thr Number of worker threads (goroutines) to use.
+// / @return benchResult The elapsed time and allocation statistics for `steps` updates.
+func runSingleBenchmark(steps int, thr int) benchResult {
+	wator.Threads = thr
+	runtime.GOMAXPROCS(wator.Threads)
+
+	// fixed seed so all runs start with same initial world
+	wator.Seed(benchSeed)
+	wator.InitWorld()
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+	for i := 0; i < steps; i++ {
+		wator.Update()
+	}
+	elapsed := time.Since(start)
+
+	runtime.ReadMemStats(&after)
+	runtime.GC()
+
+	return benchResult{
+		elapsed:    elapsed,
+		allocBytes: after.TotalAlloc - before.TotalAlloc,
+		mallocs:    after.Mallocs - before.Mallocs,
+		gcCount:    after.NumGC - before.NumGC,
+	}
+}
+
+// / @brief Run a set of benchmarks across multiple thread counts and write CSV results.
+// / @param csvPath File to write the CSV to; "" prints it to stdout.
+// / @param appendMode Append to `csvPath` instead of truncating it. The
+// / header is only written when the file is empty.
+// / @return error Any error opening or writing the output file.
+func runBenchmarks(csvPath string, appendMode bool) error {
+	steps := 1000 // or 500 / 1000, just keep it consistent across runs
+
+	var out io.Writer = os.Stdout
+	writeHeader := true
+	if csvPath != "" {
+		mode := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if appendMode {
+			mode = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		f, err := os.OpenFile(csvPath, mode, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if info, err := f.Stat(); err == nil && info.Size() > 0 {
+			writeHeader = false
+		}
+		out = f
+	}
+
+	w := csv.NewWriter(out)
+	if writeHeader {
+		w.Write(benchHeader)
+	}
+
+	threadConfigs := []int{1, 2, 4, 8}
+	for _, thr := range threadConfigs {
+		res := runSingleBenchmark(steps, thr)
+		seconds := res.elapsed.Seconds()
+		w.Write([]string{
+			strconv.Itoa(thr),
+			strconv.Itoa(steps),
+			strconv.FormatFloat(seconds, 'f', 6, 64),
+			strconv.FormatFloat(float64(steps)/seconds, 'f', 2, 64),
+			strconv.FormatUint(res.allocBytes, 10),
+			strconv.FormatUint(res.allocBytes/uint64(steps), 10),
+			strconv.FormatUint(res.mallocs, 10),
+			strconv.FormatUint(uint64(res.gcCount), 10),
+			runtime.Version(),
+			strconv.Itoa(runtime.GOMAXPROCS(0)),
+			strconv.Itoa(runtime.NumCPU()),
+		})
+		// flush per row so progress is visible during long runs
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		if csvPath != "" {
+			fmt.Printf("threads=%d steps=%d time=%.3fs alloc=%d B/tick gc=%d\n",
+				thr, steps, seconds, res.allocBytes/uint64(steps), res.gcCount)
+		}
+	}
+
+	if csvPath != "" {
+		fmt.Printf("results written to %s\n", csvPath)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+//go:build !js
+
+package main
+
+/// @file clipboard.go
+/// @brief Copy text to the system clipboard with the platform's tool.
+/// @details Go's standard library has no clipboard access and Ebitengine
+/// offers none, so the text is piped into the first of the usual command
+/// line tools found on `$PATH`: `pbcopy` on macOS, `wl-copy` on Wayland,
+/// `xclip` or `xsel` on X11 and `clip.exe` on Windows (and WSL). The
+/// browser build uses the JavaScript clipboard instead (clipboard_js.go).
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// / @brief Clipboard tools in the order they are tried.
+var clipboardCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"clip.exe"},
+}
+
+// / @brief Put `text` on the clipboard.
+// / @return error If no tool is installed or the tool fails.
+func copyToClipboard(text string) error {
+	for _, c := range clipboardCommands {
+		path, err := exec.LookPath(c[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, c[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		return cmd.Run()
+	}
+	return errors.New("no clipboard tool found (pbcopy, wl-copy, xclip, xsel or clip.exe)")
+}
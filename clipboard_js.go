@@ -0,0 +1,26 @@
+//go:build js
+
+package main
+
+/// @file clipboard_js.go
+/// @brief Copy text to the clipboard in the browser build.
+/// @details Uses `navigator.clipboard.writeText`, which browsers only offer
+/// to pages served over HTTPS or from localhost. The write completes
+/// asynchronously; a rejected write (e.g. missing permission) is not
+/// reported, but the text is also printed to the console by the caller.
+
+import (
+	"errors"
+	"syscall/js"
+)
+
+// / @brief Put `text` on the clipboard.
+// / @return error If the page has no clipboard access at all.
+func copyToClipboard(text string) error {
+	clip := js.Global().Get("navigator").Get("clipboard")
+	if clip.IsUndefined() {
+		return errors.New("no clipboard access (the page must be served over HTTPS or from localhost)")
+	}
+	clip.Call("writeText", text)
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief The grid of the loaded world as text.
+func gridText() string {
+	var b strings.Builder
+	wator.WriteText(&b)
+	return b.String()
+}
+
+// / @brief Each compare world runs exactly like a world of its own seed.
+func TestCompareSeeds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "b.json")
+	if err := os.WriteFile(path, []byte(`{"shark_breed": 5}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := wator.SetSize(40, 30); err != nil {
+		t.Fatal(err)
+	}
+	wator.NumFish, wator.NumShark, wator.Threads = 300, 60, 1
+	const ticks = 8
+
+	want := func(seed int64, opts ...wator.Option) string {
+		w, err := wator.NewWorld(append(opts, wator.WithSeed(seed))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < ticks; i++ {
+			if err := w.Step(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.String()
+	}
+	wantA, wantB := want(7), want(8, wator.WithSharkBreed(5))
+	if wantA == wantB {
+		t.Fatal("reference worlds are identical")
+	}
+
+	if err := setupCompare(path, 7); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		worldA.Unload()
+		compareMode = false
+	}()
+	for i := 0; i < ticks; i++ {
+		if err := stepBoth(nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := gridText(); got != wantA {
+		t.Errorf("world A differs from a run with seed 7")
+	}
+	if got := worldB.String(); got != wantB {
+		t.Errorf("world B differs from a run with seed 8 and shark_breed 5")
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+/// @file config.go
+/// @brief Simulation and display settings from a config file.
+/// @details `-config run.json` sets the parameters from a JSON object,
+/// e.g. `{"fish": 20000, "shark_breed": 10}`; `.yaml`/`.yml` and `.toml`
+/// files hold the same fields in those formats (see scenario.go). Every
+/// field is optional: a field that is absent keeps its default (see
+/// `defaultConfig`), while a field that is present is used as given, so
+/// `"fish_starve": 0` explicitly disables fish starvation even if a later
+/// default changes. A JSON null counts as absent. Unknown fields, values of
+/// the wrong type and anything after the object are rejected with the
+/// field or line at fault. Flags given on the command line win over the
+/// file, and the result is checked by `wator.ValidateConfig()` like any
+/// other setting.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief All settings that can be given in a config file.
+type Config struct {
+	Fish             int     `json:"fish"`               // initial fish
+	Sharks           int     `json:"sharks"`             // initial sharks
+	FishBreed        int     `json:"fish_breed"`         // ticks before a fish breeds
+	SharkBreed       int     `json:"shark_breed"`        // ticks before a shark breeds
+	SharkStarve      int     `json:"shark_starve"`       // ticks a shark survives without eating
+	FishStarve       int     `json:"fish_starve"`        // ticks a fish survives without breeding, 0 = never starves
+	FishBreedJitter  int     `json:"fish_breed_jitter"`  // +/- spread of a fish's breed timer after breeding
+	SharkBreedJitter int     `json:"shark_breed_jitter"` // +/- spread of a shark's breed timer after breeding
+	FishStayProb     float64 `json:"fish_stay_prob"`     // probability a fish stays although it could move
+	SharkMoveProb    float64 `json:"shark_move_prob"`    // probability a shark that did not eat moves
+	NoFishBreed      bool    `json:"no_fish_breed"`      // fish never breed
+	NoSharkBreed     bool    `json:"no_shark_breed"`     // sharks never breed
+	SharkVision      int     `json:"shark_vision"`       // distance sharks spot fish from
+	Stacking         bool    `json:"stacking"`           // fish form schools
+	MaxStack         int     `json:"max_stack"`          // largest school
+	Threads          int     `json:"threads"`            // worker goroutines
+	TPS              float64 `json:"tps"`                // target ticks per second, 0 = unthrottled
+	Width            int     `json:"width"`              // grid width in cells
+	Height           int     `json:"height"`             // grid height in cells
+	Theme            string  `json:"theme"`              // color theme
+	BgColor          string  `json:"bg_color"`           // hex override of the background color, "" = theme's
+	FishColor        string  `json:"fish_color"`         // hex override of the fish color
+	SharkColor       string  `json:"shark_color"`        // hex override of the shark color
+	Fade             bool    `json:"fade"`               // crossfade cell colors
+	FadeFrames       int     `json:"fade_frames"`        // frames a crossfade takes
+	ShowTiles        bool    `json:"show_tiles"`         // draw the tile boundaries
+	TintTiles        bool    `json:"tint_tiles"`         // tint tiles by worker
+}
+
+// / @brief Current settings as a `Config`.
+func currentConfig() Config {
+	return Config{
+		Fish:             wator.NumFish,
+		Sharks:           wator.NumShark,
+		FishBreed:        wator.FishBreed,
+		SharkBreed:       wator.SharkBreed,
+		SharkStarve:      wator.SharkStarve,
+		FishStarve:       wator.FishStarve,
+		FishBreedJitter:  wator.FishBreedJitter,
+		SharkBreedJitter: wator.SharkBreedJitter,
+		FishStayProb:     wator.FishStayProb,
+		SharkMoveProb:    wator.SharkMoveProb,
+		NoFishBreed:      wator.NoFishBreed,
+		NoSharkBreed:     wator.NoSharkBreed,
+		SharkVision:      wator.SharkVision,
+		Stacking:         wator.Stacking,
+		MaxStack:         wator.MaxStack,
+		Threads:          wator.Threads,
+		TPS:              simTPS,
+		Width:            gridWidth,
+		Height:           gridHeight,
+		Theme:            themeName,
+		BgColor:          bgHex,
+		FishColor:        fishHex,
+		SharkColor:       sharkHex,
+		Fade:             fade,
+		FadeFrames:       fadeFrames,
+		ShowTiles:        showTiles,
+		TintTiles:        tintTiles,
+	}
+}
+
+// / @brief Defaults for fields a config file leaves out.
+// / @details Taken from the initial values of the settings, so they always
+// / match the flag defaults.
+var defaultConfig = currentConfig()
+
+// / @brief Make `c` the current settings.
+func applyConfig(c Config) {
+	wator.NumFish, wator.NumShark = c.Fish, c.Sharks
+	wator.FishBreed, wator.SharkBreed, wator.SharkStarve, wator.FishStarve = c.FishBreed, c.SharkBreed, c.SharkStarve, c.FishStarve
+	wator.FishBreedJitter, wator.SharkBreedJitter = c.FishBreedJitter, c.SharkBreedJitter
+	wator.FishStayProb, wator.SharkMoveProb = c.FishStayProb, c.SharkMoveProb
+	wator.NoFishBreed, wator.NoSharkBreed = c.NoFishBreed, c.NoSharkBreed
+	wator.SharkVision = c.SharkVision
+	wator.Stacking, wator.MaxStack = c.Stacking, c.MaxStack
+	wator.Threads = c.Threads
+	simTPS = c.TPS
+	gridWidth, gridHeight = c.Width, c.Height
+	themeName, bgHex, fishHex, sharkHex = c.Theme, c.BgColor, c.FishColor, c.SharkColor
+	fade, fadeFrames = c.Fade, c.FadeFrames
+	showTiles, tintTiles = c.ShowTiles, c.TintTiles
+}
+
+// / @brief Parse a config file's contents on top of `defaultConfig`.
+// / @return Config The resulting settings.
+// / @return error A description of the first problem found.
+func decodeConfig(data []byte) (Config, error) {
+	cfg := defaultConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, explainConfigError(data, err)
+	}
+	if dec.More() {
+		return cfg, fmt.Errorf("line %d: unexpected data after the config object", lineAt(data, dec.InputOffset()))
+	}
+	return cfg, nil
+}
+
+// / @brief Turn a decoding error into a message naming the field or line.
+func explainConfigError(data []byte, err error) error {
+	var syn *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syn):
+		return fmt.Errorf("line %d: %v", lineAt(data, syn.Offset), err)
+	case errors.As(err, &typ):
+		return fmt.Errorf("field %q: a %s cannot be used as %s", typ.Field, typ.Value, typ.Type)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		name := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return fmt.Errorf("unknown field %s (known fields: %s)", name, strings.Join(configFields(), ", "))
+	}
+	return err
+}
+
+// / @brief 1-based line number of byte offset `off` in `data`.
+func lineAt(data []byte, off int64) int {
+	if off > int64(len(data)) {
+		off = int64(len(data))
+	}
+	return 1 + bytes.Count(data[:off], []byte("\n"))
+}
+
+// / @brief JSON names of all `Config` fields, sorted.
+func configFields() []string {
+	t := reflect.TypeOf(Config{})
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		names = append(names, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+	}
+	sort.Strings(names)
+	return names
+}
+
+// / @brief Load `path` and apply it, keeping flags set on the command line.
+// / @details Must run after `flag.Parse()`.
+// / @return error Any error reading or parsing the file, prefixed with `path`.
+func loadConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = yamlToJSON(data)
+	case ".toml":
+		data, err = tomlToJSON(data)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	cfg, err := decodeConfig(data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	explicit := map[string]string{}
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = f.Value.String() })
+	applyConfig(cfg)
+	for name, v := range explicit {
+		flag.Set(name, v)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// / @brief Fields a config leaves out keep their defaults, while fields it
+// / sets are used as given, zero included; null counts as absent.
+func TestDecodePartialConfig(t *testing.T) {
+	if defaultConfig.FishBreed == 0 || defaultConfig.SharkStarve == 0 || defaultConfig.Theme == "" {
+		t.Fatalf("defaults %+v: the zero checks need fields that are not zero by default", defaultConfig)
+	}
+	for _, tc := range []struct {
+		name string
+		data string
+		edit func(*Config)
+	}{
+		{"empty", `{}`, func(*Config) {}},
+		{"partial", `{"fish": 20000, "shark_breed": 10}`, func(c *Config) { c.Fish, c.SharkBreed = 20000, 10 }},
+		{"explicit zero", `{"fish_breed": 0, "shark_starve": 0}`, func(c *Config) { c.FishBreed, c.SharkStarve = 0, 0 }},
+		{"null", `{"fish_breed": null, "shark_starve": null}`, func(*Config) {}},
+		{"empty string", `{"theme": ""}`, func(c *Config) { c.Theme = "" }},
+	} {
+		cfg, err := decodeConfig([]byte(tc.data))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		want := defaultConfig
+		tc.edit(&want)
+		if cfg != want {
+			t.Errorf("%s: %s gives\n%+v\nwant\n%+v", tc.name, tc.data, cfg, want)
+		}
+	}
+}
+
+// / @brief Unknown fields, wrong types, bad syntax and trailing data are
+// / rejected with a message naming the field or line.
+func TestDecodeConfigErrors(t *testing.T) {
+	for _, tc := range []struct {
+		data string
+		want []string
+	}{
+		{`{"fish": 10, "fsh": 1}`, []string{`unknown field "fsh"`, "known fields: ", "fish, fish_breed"}},
+		{`{"fish": "many"}`, []string{`field "fish"`, "string"}},
+		{"{\n  \"fish\": 10,\n  \"sharks\": 5,,\n}", []string{"line 3"}},
+		{"{\"fish\": 10}\n{\"sharks\": 5}", []string{"line 2", "unexpected data after the config object"}},
+	} {
+		_, err := decodeConfig([]byte(tc.data))
+		if err == nil {
+			t.Errorf("%q was accepted", tc.data)
+			continue
+		}
+		for _, w := range tc.want {
+			if !strings.Contains(err.Error(), w) {
+				t.Errorf("%q: error %q does not mention %q", tc.data, err, w)
+			}
+		}
+	}
+}
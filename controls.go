@@ -0,0 +1,221 @@
+//go:build !headless
+
+package main
+
+/// @file controls.go
+/// @brief Clickable control bar below the grid.
+/// @details The bottom `barHeight` pixels of the window hold a row of
+/// buttons, so the simulation can be driven without knowing the hotkeys:
+///
+///	Pause/Play  Space  stop or resume ticking (also resumes after Backspace)
+///	Step        N      run a single tick (pausing first if running)
+///	Reset              build a new random world (both worlds in compare mode)
+///	Slower      -      halve the tick rate (down to one tick every 8 seconds)
+///	Faster      +      double the tick rate (up to 256 ticks per frame; with -async past the top: unthrottled)
+///	Snapshot           write the grid (world A in compare mode) to wator-<tick>.png
+///	Tune        P      show or hide the tuning panel
+///
+/// Next to the buttons the bar shows the tick, rate and seed, or the result
+/// of a snapshot until the next click. Clicks and taps on a touch screen are
+/// hit-tested once per tick in `frame()` and, like the tuning panel, applied
+/// under `worldMu`; rate changes go through `applyLiveConfig()` like PUT
+/// /config. The keys next to a button do the same as clicking it, and the
+/// other keyboard shortcuts keep working alongside the buttons.
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// / @brief Height of the control bar in window pixels.
+const barHeight = 24
+
+// / @brief Lowest and highest tick rates the speed buttons step through.
+// / @details The top is 256 ticks for each of Ebiten's 60 updates a second.
+const minButtonTPS, maxButtonTPS = 1.0 / 8, 15360
+
+// / @brief Text at the right end of the bar, as last read under `worldMu`.
+var controlStatus string
+
+// / @brief Result of the last snapshot, shown instead of the tick and rate
+// / until the next click.
+var controlMsg string
+
+// / @brief A button of the bar.
+type controlButton struct {
+	label  func() string
+	action func()
+	keys   []ebiten.Key // hotkeys doing the same as a click
+}
+
+var controlButtons = []controlButton{
+	{func() string {
+		if paused {
+			return "Play"
+		}
+		return "Pause"
+	}, func() { paused = !paused }, []ebiten.Key{ebiten.KeySpace}},
+	{func() string { return "Step" }, func() {
+		paused = true
+		stepPending = true
+	}, []ebiten.Key{ebiten.KeyN}},
+	{func() string { return "Reset" }, resetControls, nil},
+	{func() string { return "Slower" }, func() { changeSpeed(false) }, []ebiten.Key{ebiten.KeyMinus, ebiten.KeyKPSubtract}},
+	{func() string { return "Faster" }, func() { changeSpeed(true) }, []ebiten.Key{ebiten.KeyEqual, ebiten.KeyKPAdd}},
+	{func() string { return "Snapshot" }, snapshotControls, nil},
+	{func() string { return "Tune" }, func() { showTune = !showTune }, nil},
+}
+
+// / @brief Labels as last read under `worldMu`, for drawing.
+var controlLabels = make([]string, len(controlButtons))
+
+// / @brief Rebuild the world, or both worlds in compare mode.
+func resetControls() {
+	resetWorld(nil)
+	if compareMode {
+		worldB.Reset()
+	}
+}
+
+// / @brief Halve or double the tick rate.
+// / @details A rate of 0 stands for 60 ticks per second, or with
+// / `-async` for an unthrottled simulation, which is above every button step.
+func changeSpeed(up bool) {
+	tps := simTPS
+	if tps <= 0 {
+		if async {
+			if up {
+				return
+			}
+			tps = 2 * maxButtonTPS
+		} else {
+			tps = 60
+		}
+	}
+	if up {
+		tps *= 2
+		if tps > maxButtonTPS {
+			tps = maxButtonTPS
+			if async {
+				tps = 0
+			}
+		}
+	} else if tps /= 2; tps < minButtonTPS {
+		tps = minButtonTPS
+	}
+	applyLiveConfig(liveConfigPatch{TPS: &tps})
+}
+
+// / @brief Write the loaded world to a PNG named after the tick.
+func snapshotControls() {
+	path := fmt.Sprintf("wator-%06d.png", wator.Tick)
+	if err := writePNG(path, wator.Grid); err != nil {
+		controlMsg = err.Error()
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	controlMsg = "saved " + path
+	fmt.Fprintln(os.Stderr, controlMsg)
+}
+
+// / @brief Touches that started this tick, reused by `pointerPressed()`.
+var newTouches []ebiten.TouchID
+
+// / @brief Window position of this tick's left click or first new touch.
+// / @return ok False if there was neither.
+func pointerPressed() (x, y int, ok bool) {
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		x, y = ebiten.CursorPosition()
+		return x, y, true
+	}
+	newTouches = inpututil.AppendJustPressedTouchIDs(newTouches[:0])
+	if len(newTouches) > 0 {
+		x, y = ebiten.TouchPosition(newTouches[0])
+		return x, y, true
+	}
+	return 0, 0, false
+}
+
+// / @brief Window rectangle of button `i` within the bar.
+func buttonRect(i int) (x, y, w, h int) {
+	x = 4
+	for j := 0; j < i; j++ {
+		x += buttonWidth(j) + 4
+	}
+	return x, win.winH - barHeight + 2, buttonWidth(i), barHeight - 4
+}
+
+// / @brief Width of button `i`, sized for its longest label.
+func buttonWidth(i int) int {
+	// the debug font is 6 pixels wide; Pause/Play is sized for "Pause"
+	n := len(controlLabels[i])
+	if i == 0 {
+		n = len("Pause")
+	}
+	return 6*n + 12
+}
+
+// / @brief Handle clicks and taps on the bar and the buttons' hotkeys;
+// / called once per frame without `worldMu`.
+func updateControls() {
+	worldMu.Lock()
+	defer worldMu.Unlock()
+	if mx, my, ok := pointerPressed(); ok {
+		for i, b := range controlButtons {
+			x, y, w, h := buttonRect(i)
+			if mx >= x && mx < x+w && my >= y && my < y+h {
+				controlMsg = ""
+				b.action()
+				break
+			}
+		}
+	}
+	for _, b := range controlButtons {
+		for _, k := range b.keys {
+			if inpututil.IsKeyJustPressed(k) {
+				controlMsg = ""
+				b.action()
+				break
+			}
+		}
+	}
+	for i, b := range controlButtons {
+		controlLabels[i] = b.label()
+	}
+	rate := fmt.Sprintf("%g tps", simTPS)
+	if simTPS <= 0 {
+		rate = "60 tps"
+		if async {
+			rate = "unthrottled"
+		}
+	}
+	controlStatus = fmt.Sprintf("tick %d, %s, seed %d", wator.Tick, rate, runSeed)
+	if controlMsg != "" {
+		controlStatus = controlMsg
+	}
+}
+
+// / @brief Draw the bar at the bottom of the window.
+func drawControls(window *ebiten.Image) {
+	top := float32(win.winH - barHeight)
+	vector.FillRect(window, 0, top, float32(win.winW), barHeight, color.RGBA{0x20, 0x20, 0x20, 0xff}, false)
+	mx, my := ebiten.CursorPosition()
+	for i := range controlButtons {
+		x, y, w, h := buttonRect(i)
+		c := color.RGBA{0x50, 0x50, 0x50, 0xff}
+		if mx >= x && mx < x+w && my >= y && my < y+h {
+			c = color.RGBA{0x78, 0x78, 0x78, 0xff}
+		}
+		vector.FillRect(window, float32(x), float32(y), float32(w), float32(h), c, false)
+		ebitenutil.DebugPrintAt(window, controlLabels[i], x+6, y+2)
+	}
+	x, _, w, _ := buttonRect(len(controlButtons) - 1)
+	ebitenutil.DebugPrintAt(window, controlStatus, x+w+12, int(top)+4)
+}
@@ -0,0 +1,102 @@
+//go:build !headless
+
+package main
+
+/// @file dirty.go
+/// @brief Redraw and upload only the parts of the world that changed.
+/// @details A `dirtyRender` remembers the cell states its image shows. Each
+/// frame only the cells whose state differs are repainted in the CPU-side
+/// pixels, and only the bands of `dirtyBand` cell rows that contain one are
+/// uploaded, each run of neighboring bands with one `WritePixels()` on a
+/// sub-image; full-width bands are contiguous in the pixel buffer, so they
+/// upload without copying. A paused or slow world then costs one byte
+/// comparison per cell and no upload at all. Everything else still takes
+/// the full path of `renderView()`: fading (every cell may be mid-blend),
+/// the tile overlay (drawn onto the uploaded image, so it must be replaced
+/// as a whole, also in the frame after it is switched off), a palette
+/// change and the first frame.
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// / @brief Cell rows per upload band.
+const dirtyBand = 8
+
+// / @brief What an image currently shows, for `present()`.
+type dirtyRender struct {
+	cells   []uint8 // state shown in each cell (y*Width+x); empty = unknown
+	pal     [3]color.RGBA
+	overlay bool // the tile overlay was drawn on the image last frame
+	bands   []bool
+}
+
+var worldDirty dirtyRender
+var cmpDirty dirtyRender
+
+// / @brief Draw `cells` into `img` and bring `dst` up to date with it.
+// / @param f The fade buffer belonging to `img`.
+func (d *dirtyRender) present(dst *ebiten.Image, f *fadeBuffer, img *image.RGBA, cells []uint8) {
+	colors := [3]color.RGBA{pal.bg, pal.fish, pal.shark}
+	fading := fade && fadeFrames > 0
+	overlay := showTiles || tintTiles
+	if fading || overlay || d.overlay || colors != d.pal || len(d.cells) != wator.Width*wator.Height {
+		renderView(f, img, cells)
+		dst.WritePixels(img.Pix)
+		d.overlay = overlay
+		d.pal = colors
+		d.cells = d.cells[:0]
+		if !fading {
+			d.cells = append(d.cells, cells...)
+		}
+		return
+	}
+	f.state = nil // as in renderView: fading restarts from the exact colors
+
+	nBands := (wator.Height + dirtyBand - 1) / dirtyBand
+	if len(d.bands) != nBands {
+		d.bands = make([]bool, nBands)
+	}
+	clear(d.bands)
+	for y := 0; y < wator.Height; y++ {
+		row := cells[y*wator.Width : (y+1)*wator.Width]
+		shown := d.cells[y*wator.Width : (y+1)*wator.Width]
+		for x, s := range row {
+			if s == shown[x] {
+				continue
+			}
+			shown[x] = s
+			d.bands[y/dirtyBand] = true
+			c := colors[s]
+			for j := 0; j < scale; j++ {
+				p := img.Pix[(y*scale+j)*img.Stride+4*x*scale:]
+				for i := 0; i < scale; i++ {
+					p[4*i], p[4*i+1], p[4*i+2], p[4*i+3] = c.R, c.G, c.B, c.A
+				}
+			}
+		}
+	}
+
+	for b := 0; b < nBands; {
+		if !d.bands[b] {
+			b++
+			continue
+		}
+		e := b + 1
+		for e < nBands && d.bands[e] {
+			e++
+		}
+		y0, y1 := b*dirtyBand*scale, min(e*dirtyBand, wator.Height)*scale
+		if y0 == 0 && y1 == wator.Height*scale {
+			dst.WritePixels(img.Pix)
+		} else {
+			sub := dst.SubImage(image.Rect(0, y0, wator.Width*scale, y1)).(*ebiten.Image)
+			sub.WritePixels(img.Pix[y0*img.Stride : y1*img.Stride])
+		}
+		b = e
+	}
+}
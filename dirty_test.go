@@ -0,0 +1,51 @@
+//go:build !headless
+
+package main
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// / @brief The pixels the window uploads to Ebiten equal those of
+// / `renderTo()`, frame after frame, on the incremental path as well as
+// / after a palette change.
+// / @details Compares the CPU-side image `present()` writes into the
+// / Ebiten image; reading the Ebiten image back needs a running game and
+// / so a display, which tests do not have.
+func TestPresentMatchesRenderTo(t *testing.T) {
+	defer func(old palette) { pal = old }(pal)
+	w, err := wator.NewWorld(wator.WithSize(50, 30), wator.WithFish(200), wator.WithSharks(60),
+		wator.WithThreads(1), wator.WithSeed(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Load()
+	defer w.Unload()
+
+	var d dirtyRender
+	var f fadeBuffer
+	dst := ebiten.NewImage(wator.Width*scale, wator.Height*scale)
+	pix := newGridImage()
+	want := newGridImage()
+	for frame := 0; frame < 12; frame++ {
+		if frame == 6 {
+			pal = themes["colorblind"]
+		}
+		d.present(dst, &f, pix, wator.Grid)
+		renderTo(want, wator.Grid)
+		if !bytes.Equal(pix.Pix, want.Pix) {
+			t.Fatalf("frame %d: presented pixels differ from renderTo", frame)
+		}
+		if frame != 0 && frame != 6 && !slices.Contains(d.bands, true) {
+			t.Fatalf("frame %d did not repaint incrementally", frame)
+		}
+		if err := w.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief The fingerprint `-break-at K -dump` prints and writes is the one
+// / of the same world simulated for K ticks in process.
+func TestBreakAtDumpFingerprint(t *testing.T) {
+	const k = 17
+	path := filepath.Join(t.TempDir(), "at.json")
+	out := runMain(t, append([]string{"-seed", "9", "-break-at", fmt.Sprint(k), "-dump", path}, smallRun...)...)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dump struct {
+		Tick        int    `json:"tick"`
+		Fingerprint string `json:"fingerprint"`
+	}
+	if err := json.Unmarshal(data, &dump); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := wator.NewWorld(wator.WithSize(60, 40), wator.WithFish(300), wator.WithSharks(80), wator.WithThreads(1),
+		wator.WithFishBreed(3), wator.WithSharkBreed(8), wator.WithSharkStarve(3), wator.WithFishStarve(0), wator.WithSeed(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < k; i++ {
+		if err := w.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var want string
+	w.Do(func() { want = fmt.Sprintf("%016x", wator.TickFingerprint()) })
+
+	if dump.Tick != k || dump.Fingerprint != want {
+		t.Errorf("dump at tick %d has fingerprint %s, %d ticks in process give %s", dump.Tick, dump.Fingerprint, k, want)
+	}
+	if !strings.Contains(out, "fingerprint "+want) {
+		t.Errorf("-break-at printed %q, want fingerprint %s", out, want)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+/// @file ensemble.go
+/// @brief Many headless runs of one configuration for ensemble statistics.
+/// @details `-ensemble N` simulates N worlds with the same settings and
+/// seeds base, base+1, ..., base+N-1, each for up to `-ticks` ticks. A run
+/// ends early once fish or sharks have died out, since the outcome is
+/// settled then; a run in which both species are still alive at the end
+/// has survived. One line per run goes to stderr as it finishes, and the
+/// aggregate is printed as CSV:
+///
+///	runs,survived,survival_rate,mean_extinction_tick,mean_peak_fish,mean_peak_sharks
+///
+/// `mean_extinction_tick` averages over the runs that did not survive and
+/// is empty if all did. The simulation state is global, so the runs execute
+/// one after another. `-on-extinct` does not apply here. An interrupt stops
+/// the ensemble and aggregates the runs finished so far.
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Outcome of one ensemble run.
+type runOutcome struct {
+	seed        int64
+	ticks       int // ticks simulated
+	survived    bool
+	extinctTick int // tick a species died out; 0 if it survived
+	peakFish    int
+	peakSharks  int
+}
+
+// / @brief Simulate one world from `seed` for up to `ticks` ticks.
+func runOnce(seed int64, ticks int) (runOutcome, error) {
+	wator.Seed(seed)
+	wator.InitWorld()
+	out := runOutcome{seed: seed}
+	observe := func() bool {
+		st := wator.CollectStats()
+		if st.Fish > out.peakFish {
+			out.peakFish = st.Fish
+		}
+		if st.Sharks > out.peakSharks {
+			out.peakSharks = st.Sharks
+		}
+		return st.Fish == 0 || st.Sharks == 0
+	}
+
+	over := observe()
+	err := headlessLoop(ticks, func() (bool, error) {
+		if over {
+			return true, nil
+		}
+		err := wator.Update()
+		over = observe()
+		return over, err
+	})
+	out.ticks = wator.Tick
+	out.survived = !over
+	if over {
+		out.extinctTick = wator.Tick
+	}
+	return out, err
+}
+
+// / @brief Aggregate statistics over a set of runs.
+type ensembleStats struct {
+	runs, survived               int
+	meanExtinction               float64 // over the runs that did not survive
+	meanPeakFish, meanPeakSharks float64
+}
+
+// / @brief Aggregate `runs`.
+func aggregateRuns(runs []runOutcome) ensembleStats {
+	var s ensembleStats
+	s.runs = len(runs)
+	extinctSum := 0
+	for _, r := range runs {
+		if r.survived {
+			s.survived++
+		} else {
+			extinctSum += r.extinctTick
+		}
+		s.meanPeakFish += float64(r.peakFish)
+		s.meanPeakSharks += float64(r.peakSharks)
+	}
+	if s.runs > 0 {
+		s.meanPeakFish /= float64(s.runs)
+		s.meanPeakSharks /= float64(s.runs)
+	}
+	if died := s.runs - s.survived; died > 0 {
+		s.meanExtinction = float64(extinctSum) / float64(died)
+	}
+	return s
+}
+
+// / @brief Run `n` seeds starting at `base` and write the aggregate CSV.
+// / @param w Receives the CSV.
+// / @param progress Receives one line per finished run.
+// / @return error The first error from `wator.Update()`.
+func runEnsemble(w, progress io.Writer, n, ticks int, base int64) error {
+	runs := make([]runOutcome, 0, n)
+	for i := 0; i < n; i++ {
+		r, err := runOnce(base+int64(i), ticks)
+		if err != nil {
+			return err
+		}
+		if headlessInterrupted {
+			break
+		}
+		runs = append(runs, r)
+		result := "survived"
+		if !r.survived {
+			result = fmt.Sprintf("extinct at tick %d", r.extinctTick)
+		}
+		fmt.Fprintf(progress, "run %d/%d seed=%d: %s, peak %d fish, %d sharks\n",
+			i+1, n, r.seed, result, r.peakFish, r.peakSharks)
+	}
+
+	s := aggregateRuns(runs)
+	if s.runs == 0 {
+		return nil
+	}
+	meanExt := ""
+	if s.survived < s.runs {
+		meanExt = fmt.Sprintf("%.1f", s.meanExtinction)
+	}
+	fmt.Fprintln(w, "runs,survived,survival_rate,mean_extinction_tick,mean_peak_fish,mean_peak_sharks")
+	fmt.Fprintf(w, "%d,%d,%.3f,%s,%.1f,%.1f\n", s.runs, s.survived,
+		float64(s.survived)/float64(s.runs), meanExt, s.meanPeakFish, s.meanPeakSharks)
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Aggregates of a few runs, including none, none extinct and all
+// / extinct.
+func TestAggregateRuns(t *testing.T) {
+	alive := func(fish, sharks int) runOutcome {
+		return runOutcome{survived: true, peakFish: fish, peakSharks: sharks}
+	}
+	dead := func(tick, fish, sharks int) runOutcome {
+		return runOutcome{extinctTick: tick, peakFish: fish, peakSharks: sharks}
+	}
+	for _, tc := range []struct {
+		name string
+		runs []runOutcome
+		want ensembleStats
+	}{
+		{"none", nil, ensembleStats{}},
+		{"one survived", []runOutcome{alive(10, 4)},
+			ensembleStats{runs: 1, survived: 1, meanPeakFish: 10, meanPeakSharks: 4}},
+		{"one extinct", []runOutcome{dead(30, 8, 2)},
+			ensembleStats{runs: 1, meanExtinction: 30, meanPeakFish: 8, meanPeakSharks: 2}},
+		{"mixed", []runOutcome{alive(10, 4), dead(30, 20, 6), dead(50, 3, 5)},
+			ensembleStats{runs: 3, survived: 1, meanExtinction: 40, meanPeakFish: 11, meanPeakSharks: 5}},
+	} {
+		if got := aggregateRuns(tc.runs); got != tc.want {
+			t.Errorf("%s: %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// / @brief An ensemble runs seeds base, base+1, ... as distinct worlds, a
+// / seed repeats its run, and the CSV aggregates all of them.
+func TestEnsembleSeeds(t *testing.T) {
+	const n, ticks, base = 4, 40, 7
+	if err := wator.SetSize(30, 20); err != nil {
+		t.Fatal(err)
+	}
+	wator.NumFish, wator.NumShark, wator.Threads = 100, 30, 1
+
+	var csv, progress strings.Builder
+	if err := runEnsemble(&csv, &progress, n, ticks, base); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(progress.String()), "\n")
+	if len(lines) != n {
+		t.Fatalf("%d progress lines, want %d:\n%s", len(lines), n, progress.String())
+	}
+	outcomes := map[string]bool{}
+	for i, line := range lines {
+		prefix := fmt.Sprintf("run %d/%d seed=%d: ", i+1, n, base+i)
+		if !strings.HasPrefix(line, prefix) {
+			t.Errorf("progress line %q, want it to start with %q", line, prefix)
+		}
+		outcomes[strings.TrimPrefix(line, prefix)] = true
+	}
+	if len(outcomes) < 2 {
+		t.Errorf("all %d seeds gave the same run: %v", n, outcomes)
+	}
+
+	a, err := runOnce(base+1, ticks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := runOnce(base+1, ticks)
+	if a != b {
+		t.Errorf("seed %d ran as %+v and as %+v", base+1, a, b)
+	}
+
+	rows := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(rows) != 2 || rows[0] != "runs,survived,survival_rate,mean_extinction_tick,mean_peak_fish,mean_peak_sharks" {
+		t.Fatalf("CSV:\n%s", csv.String())
+	}
+	if !strings.HasPrefix(rows[1], fmt.Sprintf("%d,", n)) {
+		t.Errorf("aggregate %q does not count %d runs", rows[1], n)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !headless
+
+package main
+
+/// @file extinct.go
+/// @brief The window's notice once every creature has died.
+/// @details What happens on extinction is chosen with `-on-extinct` (see
+/// wator/extinct.go); with `stop` the grid stays on screen with "extinct"
+/// across it.
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Show the extinction notice in the window.
+func drawExtinct(window *ebiten.Image) {
+	x, y := contentToWindow(wator.Width*scale/2, wator.Height*scale/2)
+	ebitenutil.DebugPrintAt(window, "extinct", x-21, y-8)
+}
@@ -0,0 +1,102 @@
+//go:build !headless
+
+package main
+
+/// @file fade.go
+/// @brief Optional crossfade of cell colors between states.
+/// @details With `-fade` (or `F` at runtime) a cell that changes state does
+/// not jump to its new color but blends linearly from the color it shows
+/// now to the new one over `-fade-frames` rendered frames, so births,
+/// moves and deaths fade in and out instead of flickering. The blend state
+/// lives in a per-cell buffer next to the rendered image and advances once
+/// per drawn frame, independent of how often the simulation ticks. Only
+/// the window uses it; exports and `renderTo()` keep the exact colors.
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+var fade bool = false
+var fadeFrames int = 4
+
+// / @brief Displayed colors of one rendered grid while fading.
+type fadeBuffer struct {
+	state []uint8 // target state of each cell
+	rgb   []uint8 // displayed color, 3 bytes per cell
+	left  []uint8 // frames until the displayed color reaches the target
+}
+
+var worldFade fadeBuffer
+var cmpFade fadeBuffer
+
+// / @brief Toggle fading from the keyboard.
+func updateFade() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+		fade = !fade
+	}
+}
+
+// / @brief Advance the fade by one frame and draw it into `img`.
+// / @details Like `renderTo()`, but each cell shows its blended color. The
+// / first call starts from the exact colors.
+func (f *fadeBuffer) render(img *image.RGBA, cells []uint8) {
+	colors := [3]color.RGBA{pal.bg, pal.fish, pal.shark}
+	n := wator.Width * wator.Height
+	if len(f.state) != n {
+		f.state = make([]uint8, n)
+		f.rgb = make([]uint8, 3*n)
+		f.left = make([]uint8, n)
+		for i, s := range cells {
+			c := colors[s]
+			f.state[i] = s
+			f.rgb[3*i], f.rgb[3*i+1], f.rgb[3*i+2] = c.R, c.G, c.B
+		}
+	}
+	frames := fadeFrames
+	if frames > 255 {
+		frames = 255
+	}
+
+	for y := 0; y < wator.Height; y++ {
+		for x := 0; x < wator.Width; x++ {
+			i := y*wator.Width + x
+			s := cells[i]
+			if s != f.state[i] {
+				f.state[i] = s
+				f.left[i] = uint8(frames)
+			}
+			p := f.rgb[3*i : 3*i+3]
+			if r := int(f.left[i]); r > 0 {
+				// a 1/r step of the remaining distance is linear overall
+				t := colors[s]
+				p[0] = uint8(int(p[0]) + (int(t.R)-int(p[0]))/r)
+				p[1] = uint8(int(p[1]) + (int(t.G)-int(p[1]))/r)
+				p[2] = uint8(int(p[2]) + (int(t.B)-int(p[2]))/r)
+				f.left[i]--
+			}
+			for j := 0; j < scale; j++ {
+				row := img.Pix[(y*scale+j-img.Rect.Min.Y)*img.Stride:]
+				for k := 0; k < scale; k++ {
+					q := row[4*(x*scale+k-img.Rect.Min.X):]
+					q[0], q[1], q[2], q[3] = p[0], p[1], p[2], 255
+				}
+			}
+		}
+	}
+}
+
+// / @brief Draw `cells` into `img`, faded if fading is on.
+// / @param f The fade buffer belonging to `img`.
+func renderView(f *fadeBuffer, img *image.RGBA, cells []uint8) {
+	if fade && fadeFrames > 0 {
+		f.render(img, cells)
+		return
+	}
+	f.state = nil // start from the exact colors when fading is switched on
+	renderTo(img, cells)
+}
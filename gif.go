@@ -0,0 +1,130 @@
+package main
+
+/// @file gif.go
+/// @brief Record the run as an animated GIF.
+/// @details `-gif out.gif` adds one frame per rendered frame by default.
+/// With `-gif-per-tick` it adds one frame per simulation tick instead, hooked
+/// into `recordTick()`, so fast-forwarded or async runs are captured tick by
+/// tick regardless of the render cadence; headless runs always record per
+/// tick. `-gif-skip N` keeps only every N-th of these frames. The frame
+/// delay is derived from the configured rate of the chosen mode
+/// (`-sim-tps`, `-headless-tps`, or 60 frames per second) times the skip,
+/// so the GIF plays at the speed of the run, and is stored in hundredths
+/// of a second, the GIF unit. Recording stops after `-gif-max-frames`
+/// frames or, with `-gif-seconds S`, once the GIF lasts S seconds; frames
+/// are kept in memory (one byte per cell each) and the file is written when
+/// the run ends. `-gif-palette` records in the colors of another theme
+/// than the one on screen.
+///
+/// In the window, `G` starts a recording to `wator-<tick>.gif` in the
+/// working directory and writes it out when pressed again (see window.go).
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"math"
+	"os"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+var gifPath string = ""
+var gifPerTick bool = false
+var gifMaxFrames int = 300
+var gifSkip int = 1
+var gifSeconds float64 = 0 // 0 = no limit
+var gifPalette string = "" // theme name, "" = the display colors
+
+var gifAnim *gif.GIF
+var gifColors color.Palette
+var gifOffered int // frames offered since the start, for -gif-skip
+var gifLength int  // total delay so far, hundredths of a second
+
+// / @brief Check the GIF settings.
+// / @return error Describes the first invalid setting.
+func validateGIF() error {
+	if gifMaxFrames < 1 || gifSkip < 1 {
+		return fmt.Errorf("gif-max-frames and gif-skip must be at least 1")
+	}
+	if gifSeconds < 0 {
+		return fmt.Errorf("gif-seconds must be non-negative")
+	}
+	if _, ok := themes[gifPalette]; gifPalette != "" && !ok {
+		return fmt.Errorf("unknown gif palette %q (want one of %v)", gifPalette, themeNames())
+	}
+	return nil
+}
+
+// / @brief Start recording to `path`, beginning with the current grid.
+func startGIF(path string) {
+	p := pal
+	if gifPalette != "" {
+		p = themes[gifPalette]
+	}
+	gifPath = path
+	gifAnim = &gif.GIF{}
+	gifColors = color.Palette{p.bg, p.fish, p.shark}
+	gifOffered, gifLength = 0, 0
+	captureGIF(wator.Grid)
+}
+
+// / @brief Frame delay in hundredths of a second for the chosen mode.
+func gifDelay() int {
+	rate := 60.0
+	switch {
+	case gifPerTick && headlessTPS > 0:
+		rate = float64(headlessTPS)
+	case simTPS > 0:
+		rate = simTPS
+	}
+	d := int(math.Round(100 / rate))
+	if d < 1 {
+		d = 1
+	}
+	return d
+}
+
+// / @brief Append `cells` as a frame, one pixel per cell.
+// / @details A no-op without `-gif`, for frames dropped by `-gif-skip` and
+// / once `gifMaxFrames` or `gifSeconds` is reached.
+func captureGIF(cells []uint8) {
+	if gifAnim == nil || len(gifAnim.Image) >= gifMaxFrames {
+		return
+	}
+	if gifSeconds > 0 && float64(gifLength) >= gifSeconds*100 {
+		return
+	}
+	gifOffered++
+	if (gifOffered-1)%gifSkip != 0 {
+		return
+	}
+	img := image.NewPaletted(image.Rect(0, 0, wator.Width, wator.Height), gifColors)
+	for y := 0; y < wator.Height; y++ {
+		copy(img.Pix[y*img.Stride:], cells[y*wator.Width:(y+1)*wator.Width])
+	}
+	d := gifDelay() * gifSkip
+	gifAnim.Image = append(gifAnim.Image, img)
+	gifAnim.Delay = append(gifAnim.Delay, d)
+	gifLength += d
+}
+
+// / @brief Write the recorded frames to the file.
+// / @return error Any error creating or encoding the file.
+func closeGIF() error {
+	if gifAnim == nil {
+		return nil
+	}
+	anim := gifAnim
+	gifAnim = nil
+	f, err := os.Create(gifPath)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, anim); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
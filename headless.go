@@ -0,0 +1,97 @@
+package main
+
+/// @file headless.go
+/// @brief Run the simulation without opening a window.
+/// @details `-headless -ticks N` simulates N ticks and prints the final
+/// population. Per-tick output comes from the usual writers (`-ndjson`);
+/// with `-compare` the interleaved CSV of compare.go is printed instead.
+///
+/// By default ticks run as fast as possible; `-headless-tps N` paces them
+/// with a `time.Ticker` to at most N per second. The ticker keeps its own
+/// schedule, so slow ticks do not make the run drift further behind. An
+/// interrupt (Ctrl+C) ends the run after the current tick and still prints
+/// the results and flushes the outputs.
+///
+/// A binary built with `-tags headless` has no window and always takes
+/// this path (see window_headless.go).
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Target ticks per second in headless mode (0 = unthrottled).
+var headlessTPS int = 0
+
+// / @brief Set once `headlessLoop` has stopped on an interrupt.
+var headlessInterrupted bool = false
+
+// / @brief Call `step` up to `ticks` times, paced by `headlessTPS`.
+// / @details Stops early when `step` reports done, on an error, or on an
+// / interrupt.
+// / @param step Runs one tick; returns true once there is nothing left to do.
+// / @return error The first error from `step`.
+func headlessLoop(ticks int, step func() (bool, error)) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	var pace <-chan time.Time
+	if headlessTPS > 0 {
+		t := time.NewTicker(time.Second / time.Duration(headlessTPS))
+		defer t.Stop()
+		pace = t.C
+	}
+
+	for i := 0; i < ticks; i++ {
+		if pace != nil {
+			select {
+			case <-pace:
+			case <-interrupt:
+				headlessInterrupted = true
+				return nil
+			}
+		} else {
+			select {
+			case <-interrupt:
+				headlessInterrupted = true
+				return nil
+			default:
+			}
+		}
+
+		worldMu.Lock()
+		done, err := step()
+		worldMu.Unlock()
+		if err != nil || done {
+			return err
+		}
+	}
+	return nil
+}
+
+// / @brief Simulate up to `ticks` ticks, stopping early on extinction.
+// / @param w Receives the final population.
+// / @return error The first error from `wator.Update()`.
+func runHeadless(w io.Writer, ticks int) error {
+	err := headlessLoop(ticks, func() (bool, error) {
+		if wator.Extinct {
+			return true, nil
+		}
+		err := wator.Update()
+		recordTick()
+		wator.Extinct = wator.CheckExtinction()
+		return wator.Extinct, err
+	})
+	if err != nil {
+		return err
+	}
+	st := wator.CollectStats()
+	fmt.Fprintf(w, "tick %d: %d fish, %d sharks\n", st.Tick, st.Fish, st.Sharks)
+	return nil
+}
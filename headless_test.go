@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// / @brief `-headless-tps` spaces the ticks out to the requested rate.
+func TestHeadlessLoopPaced(t *testing.T) {
+	defer func(old int) { headlessTPS = old }(headlessTPS)
+	headlessTPS = 100
+	const ticks = 20
+
+	steps := 0
+	start := time.Now()
+	err := headlessLoop(ticks, func() (bool, error) {
+		steps++
+		return false, nil
+	})
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if steps != ticks {
+		t.Fatalf("ran %d steps, want %d", steps, ticks)
+	}
+	// every tick waits for the ticker, the first one included
+	if want := ticks * time.Second / 100; elapsed < want-want/10 || elapsed > 5*want {
+		t.Errorf("%d ticks at 100 per second took %v, want about %v", ticks, elapsed, want)
+	}
+}
+
+// / @brief Rates a ticker cannot deliver are rejected up front.
+func TestHeadlessTPSBound(t *testing.T) {
+	defer func(old int) { headlessTPS = old }(headlessTPS)
+	for _, tc := range []struct {
+		tps int
+		ok  bool
+	}{{0, true}, {maxTPS, true}, {-1, false}, {maxTPS + 1, false}, {2e9, false}} {
+		headlessTPS = tc.tps
+		if err := validateSettings(); (err == nil) != tc.ok {
+			t.Errorf("headless-tps %d: error %v", tc.tps, err)
+		}
+	}
+}
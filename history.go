@@ -0,0 +1,99 @@
+package main
+
+/// @file history.go
+/// @brief Limited undo: a ring buffer of the last K world states.
+/// @details Enabled with `-history K`. Before every tick the full state
+/// (cells plus breed/starve timers) is copied into the ring, and Backspace
+/// restores the previous one and pauses the simulation; Enter resumes.
+///
+/// Memory: each entry holds the grid, both timer arrays and the school
+/// sizes, i.e. width*height*(2 + 2*8) bytes on 64-bit, about 2.7 MiB at
+/// 400x400, so K is capped at `maxHistory`. The RNG state is not recorded,
+/// so resuming from a restored tick continues with different random
+/// choices.
+
+import (
+	"fmt"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+const maxHistory = 100
+
+// / @brief A full copy of the simulation state.
+type worldState struct {
+	cells  []uint8
+	breed  []int
+	starve []int
+	stack  []uint8
+	tick   int
+}
+
+// / @brief Copy the current world into `s`, allocating it on first use.
+func (s *worldState) save() {
+	if s.cells == nil {
+		s.cells, s.stack = wator.NewPlane[uint8](), wator.NewPlane[uint8]()
+		s.breed, s.starve = wator.NewPlane[int](), wator.NewPlane[int]()
+	}
+	wator.CopyPlane(s.cells, wator.Grid)
+	wator.CopyPlane(s.breed, wator.BreedTimer)
+	wator.CopyPlane(s.starve, wator.StarveTimer)
+	wator.CopyPlane(s.stack, wator.StackCount)
+	s.tick = wator.Tick
+}
+
+// / @brief Make `s` the current world.
+func (s *worldState) restore() {
+	wator.CopyPlane(wator.Grid, s.cells)
+	wator.CopyPlane(wator.BreedTimer, s.breed)
+	wator.CopyPlane(wator.StarveTimer, s.starve)
+	wator.CopyPlane(wator.StackCount, s.stack)
+	wator.Tick = s.tick
+	wator.InvalidateWorklist()
+}
+
+var history []worldState // ring buffer, len == capacity
+var histNext int         // slot the next state is written to
+var histLen int          // number of valid entries
+
+// / @brief Allocate the history ring.
+// / @param k Number of states to keep; 0 disables history.
+// / @return error Non-nil if `k` is negative or above `maxHistory`.
+func setHistory(k int) error {
+	if k < 0 || k > maxHistory {
+		return fmt.Errorf("history must be between 0 and %d states, got %d", maxHistory, k)
+	}
+	history = make([]worldState, k)
+	histNext, histLen = 0, 0
+	return nil
+}
+
+// / @brief Forget the recorded states, keeping the ring's capacity.
+func clearHistory() {
+	histNext, histLen = 0, 0
+}
+
+// / @brief Record the current state; called right before each `wator.Update()`.
+func pushHistory() {
+	if len(history) == 0 {
+		return
+	}
+	history[histNext].save()
+	histNext = (histNext + 1) % len(history)
+	if histLen < len(history) {
+		histLen++
+	}
+}
+
+// / @brief Restore the most recently recorded state.
+// / @return bool False if there is nothing left to step back to.
+func stepBack() bool {
+	if histLen == 0 {
+		return false
+	}
+	histNext = (histNext - 1 + len(history)) % len(history)
+	histLen--
+	history[histNext].restore()
+	recordStateEdit()
+	return true
+}
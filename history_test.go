@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief The world with its timers, school sizes and tick, for comparing.
+func worldBytes(t *testing.T) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := wator.WriteBinary(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// / @brief Stepping back M of N ticks gives the state of tick N-M exactly,
+// / for as many steps as the ring holds.
+func TestHistoryStepBack(t *testing.T) {
+	const k, ticks = 5, 8
+	defer setHistory(0)
+	if err := setHistory(k); err != nil {
+		t.Fatal(err)
+	}
+	if err := wator.SetSize(30, 20); err != nil {
+		t.Fatal(err)
+	}
+	wator.NumFish, wator.NumShark, wator.Threads = 100, 30, 1
+	wator.Seed(4)
+	wator.InitWorld()
+
+	states := [][]byte{worldBytes(t)}
+	for i := 0; i < ticks; i++ {
+		pushHistory()
+		if err := wator.Update(); err != nil {
+			t.Fatal(err)
+		}
+		states = append(states, worldBytes(t))
+	}
+
+	for m := 1; m <= k; m++ {
+		if !stepBack() {
+			t.Fatalf("step back %d of a ring of %d failed", m, k)
+		}
+		if wator.Tick != ticks-m {
+			t.Errorf("after %d steps back at tick %d, want %d", m, wator.Tick, ticks-m)
+		}
+		if !bytes.Equal(worldBytes(t), states[ticks-m]) {
+			t.Errorf("after %d steps back the world differs from tick %d", m, ticks-m)
+		}
+	}
+	if stepBack() {
+		t.Errorf("stepped back beyond the %d recorded states", k)
+	}
+	// the ring refills after stepping back
+	pushHistory()
+	wator.Update()
+	if !stepBack() || !bytes.Equal(worldBytes(t), states[ticks-k]) {
+		t.Errorf("pushing after stepping back does not restore tick %d", ticks-k)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+/// @file http.go
+/// @brief HTTP endpoints to observe and control a running simulation.
+/// @details Started with `-http addr`. All handlers take `worldMu`, so
+/// they never observe or modify a half-finished tick.
+///
+///	GET  /state   current cells as an RLE snapshot (see wator/rle.go)
+///	POST /reset   rebuild the world; `?seed=N` reseeds the RNG first
+///	GET  /config  mutable parameters as JSON
+///	PUT  /config  update some or all of them; omitted fields are kept
+///
+/// Grid dimensions are reported by /config but cannot be changed at
+/// runtime, since the grid arrays would have to be reallocated.
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief JSON form of the runtime-mutable parameters.
+type liveConfig struct {
+	FishBreed   int     `json:"fish_breed"`
+	SharkBreed  int     `json:"shark_breed"`
+	SharkStarve int     `json:"shark_starve"`
+	FishStarve  int     `json:"fish_starve"`
+	TPS         float64 `json:"tps"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+}
+
+// / @brief Partial update accepted by PUT /config.
+type liveConfigPatch struct {
+	FishBreed   *int     `json:"fish_breed"`
+	SharkBreed  *int     `json:"shark_breed"`
+	SharkStarve *int     `json:"shark_starve"`
+	FishStarve  *int     `json:"fish_starve"`
+	TPS         *float64 `json:"tps"`
+	Width       *int     `json:"width"`
+	Height      *int     `json:"height"`
+}
+
+// / @brief Current parameters; the caller holds `worldMu`.
+func currentLiveConfig() liveConfig {
+	return liveConfig{
+		FishBreed:   wator.FishBreed,
+		SharkBreed:  wator.SharkBreed,
+		SharkStarve: wator.SharkStarve,
+		FishStarve:  wator.FishStarve,
+		TPS:         simTPS,
+		Width:       wator.Width,
+		Height:      wator.Height,
+	}
+}
+
+// / @brief Apply a patch atomically; the caller holds `worldMu`.
+// / @details Either every field is applied or, if the result would be
+// / invalid, none is. Changes only affect timers set from now on; creatures
+// / keep the timers they already have.
+// / @return error Non-nil if the patch is rejected.
+func applyLiveConfig(p liveConfigPatch) error {
+	if (p.Width != nil && *p.Width != wator.Width) || (p.Height != nil && *p.Height != wator.Height) {
+		return fmt.Errorf("grid size is fixed at %dx%d and cannot be changed at runtime", wator.Width, wator.Height)
+	}
+
+	old := currentLiveConfig()
+	set := func(dst *int, v *int) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	set(&wator.FishBreed, p.FishBreed)
+	set(&wator.SharkBreed, p.SharkBreed)
+	set(&wator.SharkStarve, p.SharkStarve)
+	set(&wator.FishStarve, p.FishStarve)
+	if p.TPS != nil {
+		simTPS = *p.TPS
+	}
+
+	if err := validateSettings(); err != nil {
+		wator.FishBreed, wator.SharkBreed, wator.SharkStarve = old.FishBreed, old.SharkBreed, old.SharkStarve
+		wator.FishStarve, simTPS = old.FishStarve, old.TPS
+		return err
+	}
+	recordEdit(replayEvent{Op: "config", Config: &p})
+	return nil
+}
+
+// / @brief Rebuild the world; the caller holds `worldMu`.
+// / @details In compare mode world B is rebuilt as well, seeded with seed+1
+// / as at startup, so the two stay in step. The history is emptied, since
+// / its states belong to the world before the reset.
+// / @param seed RNG seed to use, or nil to keep the current RNG stream.
+func resetWorld(seed *int64) {
+	if seed != nil {
+		runSeed = *seed
+		wator.Seed(*seed)
+	}
+	wator.InitWorld()
+	wator.Extinct = false
+	if compareMode {
+		worldB.Do(func() {
+			if seed != nil {
+				wator.Seed(*seed + 1)
+			}
+			wator.InitWorld()
+			wator.Extinct = false
+		})
+	}
+	clearHistory()
+	paused = false
+	recordEdit(replayEvent{Op: "reset", Seed: seed})
+}
+
+func handleState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	worldMu.Lock()
+	data := wator.EncodeRLE()
+	worldMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data)
+}
+
+func handleReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var seed *int64
+	if s := r.URL.Query().Get("seed"); s != "" {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid seed: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		seed = &v
+	}
+
+	worldMu.Lock()
+	resetWorld(seed)
+	worldMu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var p liveConfigPatch
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&p); err != nil {
+			http.Error(w, "invalid config: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		worldMu.Lock()
+		err := applyLiveConfig(p)
+		worldMu.Unlock()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	default:
+		http.Error(w, "use GET or PUT", http.StatusMethodNotAllowed)
+		return
+	}
+
+	worldMu.Lock()
+	cfg := currentLiveConfig()
+	worldMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cfg)
+}
+
+// / @brief Build the handler serving all endpoints.
+func newHTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/state", handleState)
+	mux.HandleFunc("/reset", handleReset)
+	mux.HandleFunc("/config", handleConfig)
+	return mux
+}
+
+// / @brief Serve the endpoints on `addr` in the background.
+// / @details A failure to listen is logged; the simulation keeps running.
+func startHTTP(addr string) {
+	go func() {
+		if err := http.ListenAndServe(addr, newHTTPHandler()); err != nil {
+			log.Printf("http: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Send `body` to PUT /config and return the status code.
+func putConfig(t *testing.T, h http.Handler, body string) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/config", strings.NewReader(body)))
+	return rec.Code
+}
+
+// / @brief Send a request without a body and return the recorded response.
+func serve(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+// / @brief POST /reset with a seed rebuilds the world that seed gives, and
+// / bad requests leave the world alone.
+func TestResetEndpoint(t *testing.T) {
+	defer func(old int64) { runSeed = old }(runSeed)
+	if err := wator.SetSize(30, 20); err != nil {
+		t.Fatal(err)
+	}
+	wator.NumFish, wator.NumShark, wator.Threads = 100, 30, 1
+	wator.Seed(1)
+	wator.InitWorld()
+	h := newHTTPHandler()
+
+	if rec := serve(h, http.MethodPost, "/reset?seed=7"); rec.Code != http.StatusNoContent {
+		t.Fatalf("reset: status %d", rec.Code)
+	}
+	fresh := serve(h, http.MethodGet, "/state").Body.Bytes()
+	if wator.Tick != 0 || wator.CountFish() != 100 || wator.CountSharks() != 30 {
+		t.Errorf("reset world at tick %d with %d fish and %d sharks, want tick 0, 100 and 30",
+			wator.Tick, wator.CountFish(), wator.CountSharks())
+	}
+	for i := 0; i < 5; i++ {
+		wator.Update()
+	}
+	if rec := serve(h, http.MethodPost, "/reset?seed=7"); rec.Code != http.StatusNoContent {
+		t.Fatalf("second reset: status %d", rec.Code)
+	}
+	if again := serve(h, http.MethodGet, "/state").Body.Bytes(); !bytes.Equal(again, fresh) {
+		t.Error("resetting with the same seed built a different world")
+	}
+	if runSeed != 7 {
+		t.Errorf("run seed %d after reset, want 7", runSeed)
+	}
+
+	wator.Update()
+	before := serve(h, http.MethodGet, "/state").Body.Bytes()
+	for _, bad := range []struct {
+		method, target string
+		code           int
+	}{
+		{http.MethodPost, "/reset?seed=abc", http.StatusBadRequest},
+		{http.MethodGet, "/reset", http.StatusMethodNotAllowed},
+	} {
+		if rec := serve(h, bad.method, bad.target); rec.Code != bad.code {
+			t.Errorf("%s %s: status %d, want %d", bad.method, bad.target, rec.Code, bad.code)
+		}
+	}
+	if after := serve(h, http.MethodGet, "/state").Body.Bytes(); !bytes.Equal(after, before) {
+		t.Error("a refused reset changed the world")
+	}
+}
+
+// / @brief In compare mode POST /reset rebuilds both worlds as at startup.
+func TestResetCompare(t *testing.T) {
+	defer func(old int64) { runSeed = old }(runSeed)
+	path := filepath.Join(t.TempDir(), "b.json")
+	if err := os.WriteFile(path, []byte(`{"shark_breed": 5}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := wator.SetSize(40, 30); err != nil {
+		t.Fatal(err)
+	}
+	wator.NumFish, wator.NumShark, wator.Threads = 300, 60, 1
+	if err := setupCompare(path, 7); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		worldA.Unload()
+		compareMode = false
+	}()
+	freshA, freshB := gridText(), worldB.String()
+	for i := 0; i < 5; i++ {
+		if err := stepBoth(nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if rec := serve(newHTTPHandler(), http.MethodPost, "/reset?seed=7"); rec.Code != http.StatusNoContent {
+		t.Fatalf("reset: status %d", rec.Code)
+	}
+	if gridText() != freshA || wator.Tick != 0 {
+		t.Errorf("world A at tick %d is not the one seed 7 gives", wator.Tick)
+	}
+	if worldB.String() != freshB || worldB.Tick() != 0 {
+		t.Errorf("world B at tick %d is not the one seed 8 gives", worldB.Tick())
+	}
+}
+
+// / @brief A reset empties the history, so stepping back cannot return to
+// / the world before it.
+func TestResetClearsHistory(t *testing.T) {
+	defer setHistory(0)
+	if err := wator.SetSize(30, 20); err != nil {
+		t.Fatal(err)
+	}
+	wator.NumFish, wator.NumShark, wator.Threads = 100, 30, 1
+	wator.Seed(1)
+	wator.InitWorld()
+	if err := setHistory(4); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		pushHistory()
+		wator.Update()
+	}
+
+	if rec := serve(newHTTPHandler(), http.MethodPost, "/reset"); rec.Code != http.StatusNoContent {
+		t.Fatalf("reset: status %d", rec.Code)
+	}
+	if stepBack() {
+		t.Errorf("stepped back to tick %d from before the reset", wator.Tick)
+	}
+}
+
+// / @brief PUT /config changes only the timers it names, the response and
+// / GET /config show the change, and creatures born afterwards get the new
+// / timer. A patch with one invalid field applies none.
+func TestPutConfigTimers(t *testing.T) {
+	defer func(fb, sb, ss int) { wator.FishBreed, wator.SharkBreed, wator.SharkStarve = fb, sb, ss }(
+		wator.FishBreed, wator.SharkBreed, wator.SharkStarve)
+	if err := wator.SetSize(30, 20); err != nil {
+		t.Fatal(err)
+	}
+	wator.NumFish, wator.NumShark, wator.Threads = 100, 0, 1
+	wator.FishBreed, wator.SharkBreed, wator.SharkStarve = 3, 8, 3
+	wator.Seed(2)
+	wator.InitWorld()
+	h := newHTTPHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/config", strings.NewReader(`{"fish_breed": 1, "shark_starve": 6}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	want := liveConfig{FishBreed: 1, SharkBreed: 8, SharkStarve: 6, TPS: simTPS, Width: 30, Height: 20}
+	for name, body := range map[string]io.Reader{
+		"PUT response": rec.Body,
+		"GET /config":  serve(h, http.MethodGet, "/config").Body,
+	} {
+		var got liveConfig
+		if err := json.NewDecoder(body).Decode(&got); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("%s: %+v, want %+v", name, got, want)
+		}
+	}
+
+	born := 0
+	wator.OnBirth = func(x, y int, species uint8) {
+		if c := wator.CellAt(x, y); c.State == 1 {
+			born++
+			if c.Breed != 1 {
+				t.Errorf("fish born at (%d,%d) with breed timer %d after the change to 1", x, y, c.Breed)
+			}
+		}
+	}
+	defer func() { wator.OnBirth = nil }()
+	for i := 0; i < 4; i++ {
+		wator.Update()
+	}
+	if born == 0 {
+		t.Error("no fish were born after the change")
+	}
+
+	if code := putConfig(t, h, `{"fish_breed": 4, "shark_breed": -1}`); code != http.StatusUnprocessableEntity {
+		t.Errorf("negative shark_breed: status %d, want %d", code, http.StatusUnprocessableEntity)
+	}
+	if wator.FishBreed != 1 || wator.SharkBreed != 8 {
+		t.Errorf("rejected patch left fish_breed=%d, shark_breed=%d, want 1 and 8", wator.FishBreed, wator.SharkBreed)
+	}
+}
+
+// / @brief A rate too high for a ticker is refused and leaves the old one.
+func TestPutConfigTPSBound(t *testing.T) {
+	defer func(old float64) { simTPS = old }(simTPS)
+	simTPS = 0
+	h := newHTTPHandler()
+
+	if code := putConfig(t, h, `{"tps": 30}`); code != http.StatusOK {
+		t.Fatalf("tps 30: status %d", code)
+	}
+	if code := putConfig(t, h, `{"tps": 1e10}`); code != http.StatusUnprocessableEntity {
+		t.Errorf("tps 1e10: status %d, want %d", code, http.StatusUnprocessableEntity)
+	}
+	if simTPS != 30 {
+		t.Errorf("rejected patch changed tps to %g", simTPS)
+	}
+}
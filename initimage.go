@@ -0,0 +1,70 @@
+package main
+
+/// @file initimage.go
+/// @brief `-init-image`: build the initial world from a picture.
+/// @details Every pixel becomes one cell, so the grid takes the size of
+/// the image. A pixel is a fish, a shark or empty water depending on which
+/// of the palette's `fish`, `shark` and `bg` colors (see theme.go, after
+/// `-theme` and the color overrides) it is closest to, so antialiased edges
+/// from an image editor still map cleanly; pixels that are mostly
+/// transparent are empty. A `-dump file.png` of a world reads back as the
+/// same cells. Creatures start with fresh timers, as in `InitWorld()`, at
+/// tick 0.
+
+import (
+	"fmt"
+	"image/color"
+	"image/png"
+	"os"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Cell state whose palette color is nearest to `c`.
+func cellForColor(c color.Color) uint8 {
+	r, g, b, a := c.RGBA()
+	if a < 0x8000 {
+		return 0
+	}
+	// RGBA() is alpha-premultiplied; compare the straight colors
+	r, g, b = r*0xffff/a, g*0xffff/a, b*0xffff/a
+	best, bestDist := uint8(0), int64(-1)
+	for i, p := range [3]color.RGBA{pal.bg, pal.fish, pal.shark} {
+		dr := int64(r>>8) - int64(p.R)
+		dg := int64(g>>8) - int64(p.G)
+		db := int64(b>>8) - int64(p.B)
+		if d := dr*dr + dg*dg + db*db; bestDist < 0 || d < bestDist {
+			best, bestDist = uint8(i), d
+		}
+	}
+	return best
+}
+
+// / @brief Replace the world with the cells drawn in the PNG at `path`.
+// / @return error Any error reading or decoding the image, or an image
+// / size the grid cannot take.
+func loadInitImage(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	img, err := png.Decode(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	b := img.Bounds()
+	if b.Dx() != wator.Width || b.Dy() != wator.Height {
+		if err := wator.SetSize(b.Dx(), b.Dy()); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	cells := make([][]uint8, b.Dy())
+	for y := range cells {
+		cells[y] = make([]uint8, b.Dx())
+		for x := range cells[y] {
+			cells[y][x] = cellForColor(img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return wator.BuildWorld(cells)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// / @brief Environment variable holding the arguments of a child process
+// / that runs `main()` instead of the tests, separated by 0x1f.
+const mainArgsEnv = "WATOR_TEST_MAIN_ARGS"
+
+func TestMain(m *testing.M) {
+	if args, ok := os.LookupEnv(mainArgsEnv); ok {
+		os.Args = append(os.Args[:1], strings.Split(args, "\x1f")...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// / @brief Run the program with `args` in a child process of the test
+// / binary and return what it writes to stdout.
+func runMain(t *testing.T, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), mainArgsEnv+"="+strings.Join(args, "\x1f"))
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("%v: %v\n%s", args, err, stderr.String())
+	}
+	return string(out)
+}
+
+// / @brief The settings of the command-line runs in these tests.
+var smallRun = []string{"-width", "60", "-height", "40", "-fish", "300", "-sharks", "80", "-threads", "1"}
+
+// / @brief Two runs with the same `-seed` end in the same state; another
+// / seed gives another one.
+func TestSeedRepeats(t *testing.T) {
+	run := func(seed string) string {
+		return runMain(t, append([]string{"-seed", seed, "-break-at", "25"}, smallRun...)...)
+	}
+	a, b := run("42"), run("42")
+	if a != b || !strings.Contains(a, "fingerprint") {
+		t.Errorf("same seed, different runs:\n%s%s", a, b)
+	}
+	if c := run("43"); c == a {
+		t.Errorf("seeds 42 and 43 both give\n%s", a)
+	}
+}
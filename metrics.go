@@ -0,0 +1,67 @@
+package main
+
+/// @file metrics.go
+/// @brief Time-series output of the per-tick metrics.
+/// @details With `-ndjson file` the metrics of every tick (see
+/// wator/metrics.go) are appended to the file as one JSON object per line
+/// (newline-delimited JSON), starting with the initial world as tick 0.
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+var ndjsonFile *os.File
+var ndjsonBuf *bufio.Writer
+var ndjsonEnc *json.Encoder
+
+// / @brief Start writing the time series to `path` and record the current tick.
+// / @return error Any error creating the file.
+func openNDJSON(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	ndjsonFile = f
+	ndjsonBuf = bufio.NewWriter(f)
+	ndjsonEnc = json.NewEncoder(ndjsonBuf)
+	ndjsonEnc.Encode(wator.CollectStats())
+	return nil
+}
+
+// / @brief Record the metrics of the tick that just finished.
+// / @details Called after every simulated tick; also feeds
+// / `-gif-per-tick` (see gif.go), `-export-frames` (see frames.go),
+// / `-record` (see video.go), `-dump-timers-every` (see timers.go) and
+// / `-checkpoint-every` (see checkpoint.go). A no-op without any of these
+// / outputs.
+func recordTick() {
+	if gifPerTick {
+		captureGIF(wator.Grid)
+	}
+	recordFrame()
+	writeVideoFrame()
+	recordTimers()
+	recordCheckpoint()
+	if ndjsonEnc != nil {
+		ndjsonEnc.Encode(wator.CollectStats())
+	}
+	recordReplayTick()
+}
+
+// / @brief Flush and close the time-series output.
+// / @return error The first error writing or closing the file.
+func closeNDJSON() error {
+	if ndjsonFile == nil {
+		return nil
+	}
+	err := ndjsonBuf.Flush()
+	if cerr := ndjsonFile.Close(); err == nil {
+		err = cerr
+	}
+	ndjsonFile, ndjsonBuf, ndjsonEnc = nil, nil, nil
+	return err
+}
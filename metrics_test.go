@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// / @brief `-ndjson` writes one JSON object per line, from tick 0 to the last
+// / tick in order, each with the fields the run asked for.
+func TestNDJSONTimeseries(t *testing.T) {
+	const ticks = 30
+	for _, tc := range []struct {
+		name   string
+		flags  []string
+		fields []string
+	}{
+		{"plain", nil, []string{"fish", "sharks", "tick"}},
+		{"extras", []string{"-fingerprint", "-clustering"}, []string{"clustering", "fingerprint", "fish", "sharks", "tick"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "run.ndjson")
+			args := append([]string{"-headless", "-ticks", strconv.Itoa(ticks), "-seed", "5", "-ndjson", path}, tc.flags...)
+			runMain(t, append(args, smallRun...)...)
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			sc := bufio.NewScanner(f)
+			n := 0
+			for ; sc.Scan(); n++ {
+				var line map[string]any
+				if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+					t.Fatalf("line %d: %v: %s", n+1, err, sc.Bytes())
+				}
+				if keys := slices.Sorted(maps.Keys(line)); !slices.Equal(keys, tc.fields) {
+					t.Fatalf("line %d has fields %v, want %v", n+1, keys, tc.fields)
+				}
+				if tick := line["tick"].(float64); tick != float64(n) {
+					t.Fatalf("line %d is tick %g, want %d", n+1, tick, n)
+				}
+				fish, sharks := line["fish"].(float64), line["sharks"].(float64)
+				if n == 0 && (fish != 300 || sharks != 80) {
+					t.Errorf("tick 0 has %g fish and %g sharks, want 300 and 80", fish, sharks)
+				}
+				if fish < 0 || sharks < 0 || fish+sharks > 60*40 {
+					t.Errorf("tick %d: %g fish and %g sharks on a 60x40 grid", n, fish, sharks)
+				}
+			}
+			if err := sc.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if n != ticks+1 {
+				t.Errorf("%d lines, want %d (tick 0 to %d)", n, ticks+1, ticks)
+			}
+		})
+	}
+}
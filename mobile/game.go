@@ -0,0 +1,198 @@
+//go:build !headless
+
+package mobile
+
+/// @file game.go
+/// @brief Touch-driven Wa-Tor for phones and tablets.
+/// @details A small front end of its own on a `wator.World`, since the
+/// desktop one lives in package main and is built around a mouse and
+/// keyboard. The grid is scaled to fit above a bar of buttons big enough
+/// for fingers:
+///
+///	Pause/Play  stop or resume ticking
+///	Slower      halve the tick rate (down to 1 per second)
+///	Faster      double the tick rate (up to `maxTPS`)
+///	Fish        paint fish
+///	Shark       paint sharks
+///	Erase       paint empty water
+///	Reset       build a new random world
+///
+/// Touching the grid paints a square of `brushSize` cells with the
+/// selected brush under every finger, also while paused and into a world
+/// that died out. The top left corner shows the tick, rate and populations.
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// / @brief Height of the button bar in screen pixels.
+const barHeight = 48
+
+// / @brief Highest tick rate the speed buttons step through.
+const maxTPS = 480
+
+// / @brief Grid size in cells.
+const gridW, gridH = 160, 100
+
+// / @brief Side of the square painted under a finger, in cells.
+const brushSize = 3
+
+var bgColor = color.RGBA{69, 145, 196, 255}
+var fishColor = color.RGBA{255, 230, 120, 255}
+var sharkColor = color.RGBA{200, 50, 50, 255}
+
+// / @brief State of the app between two calls from Ebiten.
+type game struct {
+	world   *wator.World
+	img     *ebiten.Image // the grid, one pixel per cell
+	pix     *image.RGBA   // CPU-side pixels of `img`
+	paused  bool
+	tps     int     // ticks per second
+	due     float64 // ticks owed to the tick rate, carried between updates
+	brush   uint8   // state painted by touching the grid
+	status  string
+	touches []ebiten.TouchID // reused by each update
+
+	screenW, screenH int
+	fit, left, top   float64 // screen pixels per cell and grid position
+}
+
+// / @brief A button of the bar.
+type button struct {
+	label  func(g *game) string
+	lit    func(g *game) bool // drawn highlighted, e.g. the selected brush
+	action func(g *game)
+}
+
+var buttons = []button{
+	{func(g *game) string {
+		if g.paused {
+			return "Play"
+		}
+		return "Pause"
+	}, nil, func(g *game) { g.paused = !g.paused }},
+	{func(*game) string { return "Slower" }, nil, func(g *game) { g.tps = max(1, g.tps/2) }},
+	{func(*game) string { return "Faster" }, nil, func(g *game) { g.tps = min(maxTPS, g.tps*2) }},
+	{func(*game) string { return "Fish" }, func(g *game) bool { return g.brush == 1 }, func(g *game) { g.brush = 1 }},
+	{func(*game) string { return "Shark" }, func(g *game) bool { return g.brush == 2 }, func(g *game) { g.brush = 2 }},
+	{func(*game) string { return "Erase" }, func(g *game) bool { return g.brush == 0 }, func(g *game) { g.brush = 0 }},
+	{func(*game) string { return "Reset" }, nil, func(g *game) { g.world.Reset() }},
+}
+
+// / @brief Create the app with a random world.
+// / @return error Non-nil if the world settings are invalid.
+func newGame() (*game, error) {
+	w, err := wator.NewWorld(wator.WithSize(gridW, gridH), wator.WithFish(1200), wator.WithSharks(400))
+	if err != nil {
+		return nil, err
+	}
+	g := &game{world: w, tps: 30, brush: 1}
+	g.img = ebiten.NewImage(gridW, gridH)
+	g.pix = image.NewRGBA(image.Rect(0, 0, gridW, gridH))
+	return g, nil
+}
+
+// / @brief Screen rectangle of button `i`; the buttons share the bar evenly.
+func (g *game) buttonRect(i int) (x, y, w, h int) {
+	w = g.screenW / len(buttons)
+	return i * w, g.screenH - barHeight, w, barHeight
+}
+
+// / @brief Handle touches and advance the world by the ticks now due.
+func (g *game) Update() error {
+	g.touches = inpututil.AppendJustPressedTouchIDs(g.touches[:0])
+	for _, id := range g.touches {
+		tx, ty := ebiten.TouchPosition(id)
+		for i, b := range buttons {
+			x, y, w, h := g.buttonRect(i)
+			if tx >= x && tx < x+w && ty >= y && ty < y+h {
+				b.action(g)
+			}
+		}
+	}
+	g.touches = ebiten.AppendTouchIDs(g.touches[:0])
+	for _, id := range g.touches {
+		g.paint(ebiten.TouchPosition(id))
+	}
+
+	if g.paused {
+		g.due = 0
+	} else {
+		g.due += float64(g.tps) / float64(ebiten.TPS())
+		for ; g.due >= 1; g.due-- {
+			if err := g.world.Step(); err != nil {
+				return err
+			}
+		}
+	}
+	g.status = fmt.Sprintf("tick %d, %d tps\n%d fish, %d sharks",
+		g.world.Tick(), g.tps, g.world.Fish(), g.world.Sharks())
+	return nil
+}
+
+// / @brief Paint the brush around the cell under screen position `(sx, sy)`.
+func (g *game) paint(sx, sy int) {
+	if g.fit == 0 {
+		return
+	}
+	fx := (float64(sx) - g.left) / g.fit
+	fy := (float64(sy) - g.top) / g.fit
+	if fx < 0 || fy < 0 || fx >= gridW || fy >= gridH {
+		// in the margins or on the bar
+		return
+	}
+	cx, cy := int(fx), int(fy)
+	for dx := 0; dx < brushSize; dx++ {
+		for dy := 0; dy < brushSize; dy++ {
+			// the brush state is always valid
+			_ = g.world.SetCell(cx+dx-brushSize/2, cy+dy-brushSize/2, g.brush)
+		}
+	}
+}
+
+// / @brief Draw the grid, the status text and the bar.
+func (g *game) Draw(screen *ebiten.Image) {
+	colors := [3]color.RGBA{bgColor, fishColor, sharkColor}
+	g.world.Do(func() {
+		wator.ForEachCell(func(x, y int, s uint8) {
+			g.pix.SetRGBA(x, y, colors[s])
+		})
+	})
+	g.img.WritePixels(g.pix.Pix)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(g.fit, g.fit)
+	op.GeoM.Translate(g.left, g.top)
+	screen.DrawImage(g.img, op)
+	ebitenutil.DebugPrintAt(screen, g.status, 4, 4)
+
+	for i, b := range buttons {
+		x, y, w, h := g.buttonRect(i)
+		c := color.RGBA{0x40, 0x40, 0x40, 0xff}
+		if b.lit != nil && b.lit(g) {
+			c = color.RGBA{0x70, 0x70, 0x70, 0xff}
+		}
+		vector.FillRect(screen, float32(x+1), float32(y+1), float32(w-2), float32(h-2), c, false)
+		label := b.label(g)
+		// the debug font is 6 by 16 pixels
+		ebitenutil.DebugPrintAt(screen, label, x+(w-6*len(label))/2, y+(h-16)/2)
+	}
+}
+
+// / @brief Use the whole screen and fit the grid into it above the bar.
+func (g *game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	g.screenW, g.screenH = outsideWidth, outsideHeight
+	fw := float64(outsideWidth) / gridW
+	fh := float64(outsideHeight-barHeight) / gridH
+	g.fit = min(fw, fh)
+	g.left = (float64(outsideWidth) - gridW*g.fit) / 2
+	g.top = (float64(outsideHeight-barHeight) - gridH*g.fit) / 2
+	return outsideWidth, outsideHeight
+}
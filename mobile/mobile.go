@@ -0,0 +1,31 @@
+//go:build !headless
+
+package mobile
+
+/// @file mobile.go
+/// @brief Entry point for `ebitenmobile bind`.
+/// @details Binding this package builds an Android library (.aar) or an iOS
+/// framework that runs the game of game.go in an `EbitenView`, e.g.
+///
+///	ebitenmobile bind -target android -javapkg com.example.wator -o wator.aar ./mobile
+///	ebitenmobile bind -target ios -o Wator.framework ./mobile
+///
+/// The app only has to place the view in a layout; see "Mobile" in the
+/// README. This package is not meant to be imported by other Go code:
+/// outside Android and iOS `mobile.SetGame()` panics.
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/mobile"
+)
+
+func init() {
+	g, err := newGame()
+	if err != nil {
+		// the built-in settings are fixed, so this is a programming error
+		panic(err)
+	}
+	mobile.SetGame(g)
+}
+
+// / @brief Does nothing; gomobile only binds packages that export a function.
+func Dummy() {}
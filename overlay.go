@@ -0,0 +1,105 @@
+//go:build !headless
+
+package main
+
+/// @file overlay.go
+/// @brief Debug overlay showing how `wator.Update()` tiles the grid.
+/// @details Off by default. `T` (or `-show-tiles`) draws the tile
+/// boundaries computed by `wator.TileLayout()` for the current
+/// `wator.Threads`; `W` (or `-tint-tiles`) additionally tints each tile by
+/// the worker goroutine that processes it. A label lists the layout and how many tiles are active, so
+/// tiles left empty by rounding are easy to spot.
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+var showTiles bool = false
+var tintTiles bool = false
+
+var tileLine color.Color = color.RGBA{255, 255, 255, 200}
+
+// / @brief Toggle the overlay from the keyboard.
+func updateOverlay() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyT) {
+		showTiles = !showTiles
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyW) {
+		tintTiles = !tintTiles
+	}
+}
+
+// / @brief Distinct translucent color for worker `i`.
+func workerColor(i int) color.Color {
+	// golden-angle hue steps keep neighboring workers apart
+	h := math.Mod(float64(i)*137.508, 360) / 60
+	x := 1 - math.Abs(math.Mod(h, 2)-1)
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g = 1, x
+	case 1:
+		r, g = x, 1
+	case 2:
+		g, b = 1, x
+	case 3:
+		g, b = x, 1
+	case 4:
+		r, b = x, 1
+	default:
+		r, b = 1, x
+	}
+	const a = 70
+	return color.RGBA{uint8(r * a), uint8(g * a), uint8(b * a), a}
+}
+
+// / @brief Draw the tile overlay (if enabled) onto the grid image.
+// / @details Drawn in grid-image coordinates so it follows zoom and pan.
+// / @param img The offscreen grid image (see `display()`).
+func drawTiles(img *ebiten.Image) {
+	if !showTiles && !tintTiles {
+		return
+	}
+	cols, rows, tileW, tileH := wator.TileLayout()
+	worker := 0
+	for tx := 0; tx < cols; tx++ {
+		for ty := 0; ty < rows; ty++ {
+			startX, endX, startY, endY, ok := wator.TileBounds(tx, ty, tileW, tileH)
+			if !ok {
+				continue
+			}
+			x0, y0 := float32(startX*scale), float32(startY*scale)
+			w, h := float32((endX-startX)*scale), float32((endY-startY)*scale)
+			if tintTiles {
+				vector.FillRect(img, x0, y0, w, h, workerColor(worker), false)
+			}
+			if showTiles {
+				// right/bottom edges are drawn by the neighboring tile
+				// (or are the grid border, which wraps to x=0/y=0)
+				vector.FillRect(img, x0, y0, w, 1, tileLine, false)
+				vector.FillRect(img, x0, y0, 1, h, tileLine, false)
+			}
+			worker++
+		}
+	}
+}
+
+// / @brief Print the tile layout legend (if the overlay is enabled).
+// / @param window The window image, drawn after the grid.
+func drawTileLegend(window *ebiten.Image) {
+	if !showTiles && !tintTiles {
+		return
+	}
+	cols, rows, tileW, tileH := wator.TileLayout()
+	active := wator.CountActiveTiles(cols, rows, tileW, tileH)
+	ebitenutil.DebugPrint(window, fmt.Sprintf("tiles %dx%d of %dx%d, %d/%d active",
+		cols, rows, tileW, tileH, active, cols*rows))
+}
@@ -0,0 +1,54 @@
+package main
+
+/// @file plan.go
+/// @brief `-dry-run` report of what a run would do.
+/// @details Prints the resolved parameters, the tiling `wator.Update()`
+/// will use for the configured `wator.Threads`, an estimate of the simulation's memory
+/// footprint and the initial population, without simulating anything.
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Write the run plan for the current settings to `w`.
+// / @details Expects `wator.InitWorld()` to have been called so the initial
+// / population reflects what the run would start from.
+// / @param w Destination of the report.
+func printPlan(w io.Writer) {
+	thr := wator.WorkerThreads()
+	cols, rows, tileW, tileH := wator.TileLayout()
+
+	// tiles that end up with no cells are skipped by update()
+	active := wator.CountActiveTiles(cols, rows, tileW, tileH)
+
+	intSize := strconv.IntSize / 8
+	cells := wator.Width * wator.Height
+	gridBytes := 4 * cells            // grid, school sizes and their buffers
+	timerBytes := 4 * cells * intSize // breed/starve timers and their buffers
+
+	fmt.Fprintf(w, "grid:           %dx%d (%d cells)\n", wator.Width, wator.Height, cells)
+	if wator.Topology == wator.TopoHex {
+		fmt.Fprintf(w, "topology:       hex (six neighbors)\n")
+	}
+	fmt.Fprintf(w, "fish:           %d (breed every %d ticks, starve after %d, 0 = never)\n", wator.NumFish, wator.FishBreed, wator.FishStarve)
+	fmt.Fprintf(w, "sharks:         %d (breed every %d ticks, starve after %d)\n", wator.NumShark, wator.SharkBreed, wator.SharkStarve)
+	if len(wator.Zones) > 0 {
+		fmt.Fprintf(w, "zones:          %d (plus the global zone)\n", len(wator.Zones))
+	}
+	fmt.Fprintf(w, "seed:           %d\n", runSeed)
+	fmt.Fprintf(w, "shark hunt:     %s\n", wator.SharkHunt)
+	if wator.Stacking {
+		fmt.Fprintf(w, "stacking:       up to %d fish per cell\n", wator.MaxStack)
+	}
+	fmt.Fprintf(w, "move odds:      fish stay %g, shark move %g\n", wator.FishStayProb, wator.SharkMoveProb)
+	fmt.Fprintf(w, "threads:        %d (%s scheduler, %s engine)\n", thr, wator.Scheduler, wator.Engine)
+	fmt.Fprintf(w, "tiles:          %d cols x %d rows of %dx%d cells, %d active\n", cols, rows, tileW, tileH, active)
+	fmt.Fprintf(w, "memory:         %.1f MiB (cells %d B, timers %d B)\n",
+		float64(gridBytes+timerBytes)/(1<<20), gridBytes, timerBytes)
+	fmt.Fprintf(w, "initial fish:   %d\n", wator.CountFish())
+	fmt.Fprintf(w, "initial sharks: %d\n", wator.CountSharks())
+}
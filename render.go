@@ -0,0 +1,59 @@
+package main
+
+/// @file render.go
+/// @brief Pure-Go rendering of the grid into an `image.RGBA`.
+/// @details Needs no GPU or Ebiten context, so exporters and tests can draw
+/// the world headlessly. `display()` uses the same function and uploads the
+/// result to its Ebiten image, so both paths produce identical pixels.
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Image of the right size for `renderTo()`.
+func newGridImage() *image.RGBA {
+	return image.NewRGBA(image.Rect(0, 0, wator.Width*scale, wator.Height*scale))
+}
+
+// / @brief Draw `cells` into `img` using the current palette `pal`.
+// / @details Each cell becomes a `scale` x `scale` block. `img` must be at
+// / least `wator.Width*scale` x `wator.Height*scale` pixels (see
+// / `newGridImage()`).
+// / @param img Destination image; every covered pixel is overwritten.
+// / @param cells The grid to draw.
+func renderTo(img *image.RGBA, cells []uint8) {
+	colors := [3]color.RGBA{pal.bg, pal.fish, pal.shark}
+	for y := 0; y < wator.Height; y++ {
+		for j := 0; j < scale; j++ {
+			row := img.Pix[(y*scale+j-img.Rect.Min.Y)*img.Stride:]
+			for x := 0; x < wator.Width; x++ {
+				c := colors[cells[y*wator.Width+x]]
+				for i := 0; i < scale; i++ {
+					p := row[4*(x*scale+i-img.Rect.Min.X):]
+					p[0], p[1], p[2], p[3] = c.R, c.G, c.B, c.A
+				}
+			}
+		}
+	}
+}
+
+// / @brief Render `cells` and write them to `path` as a PNG.
+// / @return error Any error creating or encoding the file.
+func writePNG(path string, cells []uint8) error {
+	img := newGridImage()
+	renderTo(img, cells)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
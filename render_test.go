@@ -0,0 +1,68 @@
+package main
+
+import (
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Every theme, and a theme with overridden colors, writes its
+// / colors for water, fish and sharks into the PNG cell by cell.
+func TestThemePNG(t *testing.T) {
+	defer func(old palette) { pal = old }(pal)
+	if err := wator.ReadText(strings.NewReader("f.S\n.Sf")); err != nil {
+		t.Fatal(err)
+	}
+	cells := wator.NewPlane[uint8]()
+	wator.CopyPlane(cells, wator.Grid)
+
+	type theme struct{ name, bg, fish, shark string }
+	cases := []theme{{name: "override", bg: "#102030", fish: "#ffffff", shark: "#010203"}}
+	for _, name := range themeNames() {
+		cases = append(cases, theme{name: name})
+	}
+	for _, tc := range cases {
+		name := tc.name
+		if tc.bg != "" {
+			name = "default"
+		}
+		if err := applyTheme(name, tc.bg, tc.fish, tc.shark); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), tc.name+".png")
+		if err := writePNG(path, cells); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := themes[tc.name]
+		want := [3]color.RGBA{p.bg, p.fish, p.shark}
+		if tc.bg != "" {
+			want = [3]color.RGBA{{0x10, 0x20, 0x30, 255}, {255, 255, 255, 255}, {1, 2, 3, 255}}
+		}
+		if b := img.Bounds(); b.Dx() != 3*scale || b.Dy() != 2*scale {
+			t.Fatalf("%s: image is %v, want %dx%d", tc.name, b, 3*scale, 2*scale)
+		}
+		for y := 0; y < 2*scale; y++ {
+			for x := 0; x < 3*scale; x++ {
+				c := wator.CellAt(x/scale, y/scale).State
+				if got := color.RGBAModel.Convert(img.At(x, y)); got != want[c] {
+					t.Errorf("%s: pixel (%d, %d) of a cell in state %d is %v, want %v", tc.name, x, y, c, got, want[c])
+				}
+			}
+		}
+	}
+}
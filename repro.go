@@ -0,0 +1,36 @@
+package main
+
+/// @file repro.go
+/// @brief The command line that reproduces the current run.
+/// @details Used by the `C` key of the window (window.go), which copies it
+/// to the clipboard: `-seed` and every setting that changes the dynamics
+/// and differs from its default, at its current value, so live changes
+/// from the tuning panel, the speed buttons or PUT /config are included.
+/// The list of settings is the one replay files record (replay.go), plus
+/// the grid size and zones. The seed repeats the run from its first tick;
+/// a Reset without a new seed continues the random stream, so such a world
+/// is not reproduced by the seed alone.
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// / @brief The `wa-tor` arguments that reproduce the current run.
+func reproCommand() string {
+	args := []string{"wa-tor", fmt.Sprintf("-seed=%d", runSeed)}
+	names := append([]string{"width", "height", "zones"}, replayFlags...)
+	for _, name := range names {
+		f := flag.Lookup(name)
+		if f == nil || f.Value.String() == f.DefValue {
+			continue
+		}
+		v := f.Value.String()
+		if strings.ContainsAny(v, " \t\"'") {
+			v = fmt.Sprintf("%q", v)
+		}
+		args = append(args, "-"+name+"="+v)
+	}
+	return strings.Join(args, " ")
+}
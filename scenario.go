@@ -0,0 +1,151 @@
+package main
+
+/// @file scenario.go
+/// @brief YAML and TOML scenario files for `-config`.
+/// @details Scenario files are flat: one `key: value` (YAML) or
+/// `key = value` (TOML) per line with the field names of `Config`, blank
+/// lines and `#` comments, e.g.
+///
+///	# predator-heavy start
+///	fish: 20000
+///	sharks: 6000
+///	theme: colorblind
+///	bg_color: "#102030"
+///
+/// Values are integers, floats, `true`/`false` or strings; YAML also
+/// accepts unquoted strings and `null`/`~` for "keep the default". Both
+/// readers translate the file into the equivalent JSON object, so every
+/// other rule of config.go (unknown fields, types, defaults) applies
+/// unchanged. Nested mappings, lists, TOML tables and multi-line values are
+/// not supported and are rejected with the line at fault, as are duplicate
+/// keys.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// / @brief Split `line` at the first `#` that is not inside a quoted string.
+func stripComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// / @brief JSON form of a scalar written in a scenario file.
+// / @param v The value with surrounding space removed.
+// / @param bare Whether unquoted strings are allowed (YAML) or not (TOML).
+// / @return string A JSON value; "null" for a YAML null.
+// / @return error Non-nil if `v` is not a supported scalar.
+func scalarJSON(v string, bare bool) (string, error) {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') {
+		if v[len(v)-1] != v[0] {
+			return "", fmt.Errorf("unterminated string %s", v)
+		}
+		s := v[1 : len(v)-1]
+		if v[0] == '"' {
+			var err error
+			if s, err = strconv.Unquote(v); err != nil {
+				return "", fmt.Errorf("invalid string %s", v)
+			}
+		}
+		b, _ := json.Marshal(s)
+		return string(b), nil
+	}
+	if v == "true" || v == "false" {
+		return v, nil
+	}
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return v, nil
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		b, _ := json.Marshal(f)
+		return string(b), nil
+	}
+	if bare {
+		if v == "null" || v == "~" {
+			return "null", nil
+		}
+		if strings.ContainsAny(v, "[]{}") || strings.HasPrefix(v, "- ") {
+			return "", fmt.Errorf("lists and inline mappings are not supported")
+		}
+		b, _ := json.Marshal(v)
+		return string(b), nil
+	}
+	return "", fmt.Errorf("invalid value %s (strings must be quoted)", v)
+}
+
+// / @brief Translate flat `key <sep> value` lines into a JSON object.
+// / @param sep ":" for YAML, "=" for TOML.
+// / @return []byte The JSON object, one field per line in file order.
+// / @return error The first malformed line.
+func flatToJSON(data []byte, sep string, bare bool) ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteString("{\n")
+	seen := map[string]bool{}
+	for i, raw := range strings.Split(string(data), "\n") {
+		n := i + 1
+		line := strings.TrimRight(stripComment(strings.TrimSuffix(raw, "\r")), " \t")
+		if strings.TrimSpace(line) == "" || (bare && (line == "---" || line == "...")) {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return nil, fmt.Errorf("line %d: indented lines are not supported, the file must be flat", n)
+		}
+		if !bare && line[0] == '[' {
+			return nil, fmt.Errorf("line %d: tables are not supported, the file must be flat", n)
+		}
+		k := strings.Index(line, sep)
+		if k < 0 {
+			return nil, fmt.Errorf("line %d: expected key %s value", n, sep)
+		}
+		key := strings.TrimSpace(line[:k])
+		val := strings.TrimSpace(line[k+1:])
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", n)
+		}
+		if uq, err := strconv.Unquote(key); err == nil {
+			key = uq
+		}
+		if val == "" {
+			return nil, fmt.Errorf("line %d: no value for %q (nested values are not supported; quote strings that start with #)", n, key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: duplicate key %q", n, key)
+		}
+		seen[key] = true
+		js, err := scalarJSON(val, bare)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if len(seen) > 1 {
+			out.WriteString(",\n")
+		}
+		kb, _ := json.Marshal(key)
+		fmt.Fprintf(&out, "%s: %s", kb, js)
+	}
+	out.WriteString("\n}\n")
+	return out.Bytes(), nil
+}
+
+// / @brief Translate a flat YAML scenario into a JSON config object.
+func yamlToJSON(data []byte) ([]byte, error) {
+	return flatToJSON(data, ":", true)
+}
+
+// / @brief Translate a flat TOML scenario into a JSON config object.
+func tomlToJSON(data []byte) ([]byte, error) {
+	return flatToJSON(data, "=", false)
+}
@@ -0,0 +1,196 @@
+package main
+
+/// @file sim.go
+/// @brief Background simulation goroutine decoupled from rendering.
+/// @details With `-async` the simulation runs in its own goroutine at
+/// `-sim-tps` ticks per second (0 = as fast as possible) while
+/// `drawFrame()` only draws the most recently completed tick. An extinct grid is not
+/// updated until it is reset, a paused one only on single steps.
+///
+/// Snapshot synchronization: the simulation goroutine is the only writer of
+/// `wator.Grid` and the timer arrays. After each tick it copies `wator.Grid`
+/// into its private back snapshot and then, holding `snapMu`, swaps the
+/// back and front pointers. The renderer holds `snapMu` only long enough to
+/// copy the front snapshot into its own buffer, so neither side ever sees a
+/// half-written tick and drawing never blocks the simulation for longer
+/// than one array copy.
+///
+/// A tick that fails stops the goroutine; its error is kept in `simErr`,
+/// which `frame()` returns like the error of a tick it ran itself.
+
+import (
+	"sync"
+	"time"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief A completed simulation tick as seen by the renderer.
+type snapshot struct {
+	cells   []uint8
+	tick    int
+	extinct bool // the simulation halted on an empty grid
+}
+
+var (
+	snapMu    sync.Mutex
+	snapFront = &snapshot{} // last published tick, guarded by snapMu
+	snapBack  = &snapshot{} // owned by the simulation goroutine
+	snapView  = &snapshot{} // renderer's private copy
+
+	simStop chan struct{}
+	simDone sync.WaitGroup
+)
+
+// / @brief Serializes everything that reads or changes the world as a whole.
+// / @details Held around each tick (by `frame()` or the simulation
+// / goroutine) and by the HTTP handlers, so resets and parameter changes
+// / always land between two ticks.
+var worldMu sync.Mutex
+
+// / @brief Target simulation ticks per second, guarded by `worldMu`.
+// / @details With `-async` this paces the simulation goroutine (0 =
+// / unthrottled); otherwise `frame()` runs as many ticks per call of the
+// / game's `Update` as the rate is due (0 = 60, one per call). Fractions
+// / run slower than one tick per second, e.g. 0.25 for one every 4 seconds.
+var simTPS float64 = 0
+
+// / @brief Ticking is paused (Pause button, or after stepping back in
+// / history). Guarded by `worldMu`.
+var paused bool = false
+
+// / @brief Set by the Step button; the next tick clears it. Guarded by `worldMu`.
+var stepPending bool = false
+
+// / @brief Error of the tick that stopped the simulation goroutine, nil
+// / while it runs. Guarded by `worldMu`.
+var simErr error
+
+// / @brief Whether the world may advance this tick; the caller holds `worldMu`.
+// / @details True unless paused; while paused, true once per Step click.
+func runTick() bool {
+	if !paused {
+		return true
+	}
+	if stepPending {
+		stepPending = false
+		return true
+	}
+	return false
+}
+
+// / @brief Publish the current `wator.Grid` as the latest snapshot.
+// / @param n Number of the tick that produced `wator.Grid`.
+// / @param halted True if the simulation stopped on extinction after it.
+func publishSnapshot(n int, halted bool) {
+	wator.CopyPlane(snapBack.cells, wator.Grid)
+	snapBack.tick = n
+	snapBack.extinct = halted
+
+	snapMu.Lock()
+	snapFront, snapBack = snapBack, snapFront
+	snapMu.Unlock()
+}
+
+// / @brief Copy the latest published snapshot for drawing.
+// / @return *snapshot The renderer's copy; valid until the next call.
+func latestSnapshot() *snapshot {
+	snapMu.Lock()
+	wator.CopyPlane(snapView.cells, snapFront.cells)
+	snapView.tick, snapView.extinct = snapFront.tick, snapFront.extinct
+	snapMu.Unlock()
+	return snapView
+}
+
+// / @brief Start the background simulation goroutine.
+// / @details Must not be combined with calling `wator.Update()` from `frame()`.
+// / Each tick runs under `worldMu`; the rate follows `simTPS`, which may be
+// / changed while running (0 runs unthrottled). A failing tick ends the
+// / goroutine and leaves its error in `simErr`.
+func startSim() {
+	for _, s := range []*snapshot{snapFront, snapBack, snapView} {
+		s.cells = wator.NewPlane[uint8]()
+	}
+	publishSnapshot(wator.Tick, wator.Extinct)
+
+	simStop = make(chan struct{})
+	simDone.Add(1)
+	go func() {
+		defer simDone.Done()
+
+		var ticker *time.Ticker
+		rate := 0.0
+		defer func() {
+			if ticker != nil {
+				ticker.Stop()
+			}
+		}()
+
+		for {
+			worldMu.Lock()
+			want := simTPS
+			worldMu.Unlock()
+			if want != rate {
+				if ticker != nil {
+					ticker.Stop()
+					ticker = nil
+				}
+				if want > 0 {
+					ticker = time.NewTicker(time.Duration(float64(time.Second) / want))
+				}
+				rate = want
+			}
+
+			if ticker != nil {
+				select {
+				case <-simStop:
+					return
+				case <-ticker.C:
+				}
+			} else {
+				select {
+				case <-simStop:
+					return
+				default:
+				}
+			}
+
+			worldMu.Lock()
+			if !wator.Extinct && runTick() {
+				err := wator.Update()
+				recordTick()
+				wator.Extinct = wator.CheckExtinction()
+				if err != nil {
+					simErr = err
+					publishSnapshot(wator.Tick, wator.Extinct)
+					worldMu.Unlock()
+					return
+				}
+			}
+			halted := wator.Extinct
+			idle := halted || paused
+			publishSnapshot(wator.Tick, halted)
+			worldMu.Unlock()
+
+			if idle {
+				// nothing to simulate until a reset or resume; don't spin
+				select {
+				case <-simStop:
+					return
+				case <-time.After(50 * time.Millisecond):
+				}
+			}
+		}
+	}()
+}
+
+// / @brief Stop the background simulation and wait for it to exit.
+// / @details Safe to call when the simulation was never started.
+func stopSim() {
+	if simStop == nil {
+		return
+	}
+	close(simStop)
+	simDone.Wait()
+	simStop = nil
+}
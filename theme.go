@@ -0,0 +1,121 @@
+package main
+
+/// @file theme.go
+/// @brief Color palettes used to draw the ocean, fish and sharks.
+/// @details A palette is selected by name with `-theme` and individual
+/// entries can be overridden with hex colors (`-bg-color`, `-fish-color`,
+/// `-shark-color`). Everything that draws the grid reads from `pal`.
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// / @brief Colors for the three cell states.
+type palette struct {
+	bg    color.RGBA // empty water
+	fish  color.RGBA
+	shark color.RGBA
+}
+
+// / @brief Built-in palettes selectable with `-theme`.
+var themes = map[string]palette{
+	"default": {
+		bg:    color.RGBA{69, 145, 196, 255},
+		fish:  color.RGBA{255, 230, 120, 255},
+		shark: color.RGBA{200, 50, 50, 255},
+	},
+	"highcontrast": {
+		bg:    color.RGBA{0, 0, 0, 255},
+		fish:  color.RGBA{255, 255, 255, 255},
+		shark: color.RGBA{255, 0, 0, 255},
+	},
+	"grayscale": {
+		bg:    color.RGBA{32, 32, 32, 255},
+		fish:  color.RGBA{200, 200, 200, 255},
+		shark: color.RGBA{110, 110, 110, 255},
+	},
+	// blue/orange stays distinguishable under the common color deficiencies
+	"colorblind": {
+		bg:    color.RGBA{0, 45, 90, 255},
+		fish:  color.RGBA{240, 228, 66, 255},
+		shark: color.RGBA{213, 94, 0, 255},
+	},
+}
+
+// / @brief The palette currently used for drawing.
+var pal palette = themes["default"]
+
+// / @brief Theme and color overrides chosen by flags or the config file.
+// / @details Turned into `pal` by `applyTheme()` once at startup.
+var themeName string = "default"
+var bgHex string = "" // "" keeps the theme's color
+var fishHex string = ""
+var sharkHex string = ""
+
+// / @brief Returns the sorted list of built-in theme names.
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// / @brief Parse a color written as `RRGGBB`, `#RRGGBB` or `#RRGGBBAA`.
+// / @param s Hex color string.
+// / @return color.RGBA The parsed color (alpha defaults to 255).
+// / @return error Non-nil if `s` is not a valid hex color.
+func parseHexColor(s string) (color.RGBA, error) {
+	h := strings.TrimPrefix(s, "#")
+	if len(h) != 6 && len(h) != 8 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q: want RRGGBB or RRGGBBAA", s)
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q: %v", s, err)
+	}
+	if len(h) == 6 {
+		v = v<<8 | 0xff
+	}
+	return color.RGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
+
+// / @brief Select a theme and apply any per-color overrides to `pal`.
+// / @param name Theme name (see `themes`).
+// / @param bgHex Optional hex override for the background, "" to keep the theme's.
+// / @param fishHex Optional hex override for fish.
+// / @param sharkHex Optional hex override for sharks.
+// / @return error Non-nil for an unknown theme or malformed color.
+func applyTheme(name, bgHex, fishHex, sharkHex string) error {
+	p, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(themeNames(), ", "))
+	}
+
+	overrides := []struct {
+		hex string
+		dst *color.RGBA
+	}{
+		{bgHex, &p.bg},
+		{fishHex, &p.fish},
+		{sharkHex, &p.shark},
+	}
+	for _, o := range overrides {
+		if o.hex == "" {
+			continue
+		}
+		c, err := parseHexColor(o.hex)
+		if err != nil {
+			return err
+		}
+		*o.dst = c
+	}
+
+	pal = p
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+/// @file timers.go
+/// @brief `-dump-timers`: timer histograms as CSV.
+/// @details Writes `wator.TimerHistograms()` (see wator/timers.go) at the
+/// end of the run, and with `-dump-timers-every N` also after every N-th tick:
+///
+///	tick,timer,value,count
+///	120,fish_breed,0,5012
+///	120,fish_breed,1,4380
+///
+/// `timer` is `fish_breed`, `shark_breed` or `shark_starve`; every value
+/// from 0 to the largest one present gets a row, zero counts included.
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+var timersFile *os.File
+var timersBuf *bufio.Writer
+var timersEvery int = 0
+var timersTick int = -1 // last tick written
+
+// / @brief Start writing timer histograms to `path`, with the current tick
+// / if `-dump-timers-every` is set.
+// / @return error Any error creating the file.
+func openTimers(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	timersFile = f
+	timersBuf = bufio.NewWriter(f)
+	fmt.Fprintln(timersBuf, "tick,timer,value,count")
+	recordTimers()
+	return nil
+}
+
+// / @brief Append the histograms of the current tick, once per tick.
+func writeTimers() {
+	if timersBuf == nil || wator.Tick == timersTick {
+		return
+	}
+	timersTick = wator.Tick
+	h := wator.TimerHistograms()
+	for _, t := range []struct {
+		name string
+		bins []int
+	}{{"fish_breed", h.FishBreed}, {"shark_breed", h.SharkBreed}, {"shark_starve", h.SharkStarve}} {
+		for v, n := range t.bins {
+			fmt.Fprintf(timersBuf, "%d,%s,%d,%d\n", wator.Tick, t.name, v, n)
+		}
+	}
+}
+
+// / @brief Write the histograms if this tick is due; from `recordTick()`.
+func recordTimers() {
+	if timersEvery > 0 && wator.Tick%timersEvery == 0 {
+		writeTimers()
+	}
+}
+
+// / @brief Write the final histograms, then flush and close the file.
+// / @return error The first error writing or closing the file.
+func closeTimers() error {
+	if timersFile == nil {
+		return nil
+	}
+	writeTimers()
+	err := timersBuf.Flush()
+	if cerr := timersFile.Close(); err == nil {
+		err = cerr
+	}
+	timersFile, timersBuf = nil, nil
+	return err
+}
@@ -0,0 +1,211 @@
+package main
+
+/// @file tui.go
+/// @brief Interactive terminal front end for `-render tui`.
+/// @details Like `-render ascii` this needs no window or GPU, so it works
+/// over SSH, but it draws the grid in the colors of the palette (`-theme`
+/// and the color flags) as ANSI 24-bit backgrounds and takes keys:
+///
+///	Space or p  pause / resume
+///	s or n      run a single tick (and pause)
+///	+ / -       double / halve the tick rate (1 to `maxTUITPS`)
+///	q           quit
+///
+/// The grid is downsampled to the terminal like in ascii.go, with the
+/// same `-ascii-glyphs`; the two bottom rows show the tick, the
+/// populations and rate, and the keys. Keys are read without waiting for Enter by switching the terminal out
+/// of canonical mode with `stty` for the run (Ctrl+C still interrupts),
+/// so the mode needs a Unix terminal on stdin. The run ends after `-ticks`
+/// ticks, on `q` or on an interrupt; a world that died out stays on screen
+/// until `q`. The rate starts at `-headless-tps`, or 10 if that is 0.
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Highest tick rate `+` steps up to.
+const maxTUITPS = 1000
+
+// / @brief Switch the terminal on stdin to reading single keys without echo.
+// / @return restore Puts the terminal back into its previous mode.
+// / @return error If stdin is no terminal or `stty` is missing.
+func tuiRawMode() (restore func(), err error) {
+	stty := func(args ...string) (string, error) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = os.Stdin
+		out, err := cmd.Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, errors.New("-render tui needs a terminal on stdin and stty")
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, fmt.Errorf("stty: %v", err)
+	}
+	return func() { stty(saved) }, nil
+}
+
+// / @brief Draw the grid in color, at most `cols` x `rows` characters.
+// / @details Plain characters are colored blanks, half blocks take the
+// / top cell's color as foreground and the bottom one's as background, and
+// / Braille dots are drawn in the color of the most common creature under
+// / them on water.
+// / @return string The lines, each terminated by a newline.
+func renderTUI(cols, rows int) string {
+	g := newGlyphGrid(cols, rows)
+	colors := [3]color.RGBA{pal.bg, pal.fish, pal.shark}
+	var b strings.Builder
+	// per line, the last colors sent; -1 = none yet
+	var fg, bg int
+	setColors := func(f, k int) {
+		if f >= 0 && f != fg {
+			c := colors[f]
+			fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm", c.R, c.G, c.B)
+			fg = f
+		}
+		if k != bg {
+			c := colors[k]
+			fmt.Fprintf(&b, "\x1b[48;2;%d;%d;%dm", c.R, c.G, c.B)
+			bg = k
+		}
+	}
+	for cy := 0; cy < g.h; cy++ {
+		fg, bg = -1, -1
+		for cx := 0; cx < g.w; cx++ {
+			switch asciiGlyphs {
+			case "half":
+				setColors(int(g.at(cx, 2*cy)), int(g.at(cx, 2*cy+1)))
+				b.WriteRune('\u2580')
+			case "braille":
+				r, s := g.braille(cx, cy)
+				if s == 0 {
+					setColors(-1, 0)
+				} else {
+					setColors(int(s), 0)
+				}
+				b.WriteRune(r)
+			default:
+				setColors(-1, int(g.at(cx, cy)))
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	return b.String()
+}
+
+// / @brief Redraw the whole screen.
+// / @param note Shown after the rate if not empty, e.g. "paused".
+func drawTUI(w io.Writer, tps int, note string) {
+	if note != "" {
+		note = " - " + note
+	}
+	cols, rows := asciiSize()
+	bw := bufio.NewWriter(w)
+	bw.WriteString("\x1b[H")
+	bw.WriteString(renderTUI(cols, rows-1))
+	st := wator.CollectStats()
+	fmt.Fprintf(bw, "tick %d: %d fish, %d sharks, %d tps%s\x1b[K\n", st.Tick, st.Fish, st.Sharks, tps, note)
+	bw.WriteString("space pause  s step  +/- speed  q quit\x1b[K")
+	bw.Flush()
+}
+
+// / @brief Run up to `ticks` ticks in the terminal, taking keys from stdin.
+// / @return error If the terminal cannot be set up, or the first error
+// / from `wator.Update()`.
+func runTUI(w io.Writer, ticks int) error {
+	restore, err := tuiRawMode()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	keys := make(chan byte)
+	go func() {
+		r := bufio.NewReader(os.Stdin)
+		for {
+			k, err := r.ReadByte()
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- k
+		}
+	}()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	tps := headlessTPS
+	if tps <= 0 {
+		tps = 10
+	}
+	paused := false
+	fmt.Fprint(w, "\x1b[2J\x1b[?25l")
+	defer fmt.Fprint(w, "\x1b[0m\x1b[?25h\n")
+
+	for done := 0; done < ticks; {
+		note := ""
+		switch {
+		case wator.Extinct:
+			note = "extinct"
+		case paused:
+			note = "paused"
+		}
+		drawTUI(w, tps, note)
+
+		var pace <-chan time.Time
+		if !paused && !wator.Extinct {
+			pace = time.After(time.Second / time.Duration(tps))
+		}
+		step := false
+		select {
+		case k, ok := <-keys:
+			switch {
+			case !ok || k == 'q' || k == 'Q':
+				return nil
+			case k == ' ' || k == 'p':
+				paused = !paused
+			case k == 's' || k == 'n':
+				paused = true
+				step = !wator.Extinct
+			case k == '+' || k == '=':
+				tps = min(maxTUITPS, tps*2)
+			case k == '-':
+				tps = max(1, tps/2)
+			}
+		case <-interrupt:
+			headlessInterrupted = true
+			return nil
+		case <-pace:
+			step = true
+		}
+		if !step {
+			continue
+		}
+
+		worldMu.Lock()
+		err := wator.Update()
+		recordTick()
+		wator.Extinct = wator.CheckExtinction()
+		worldMu.Unlock()
+		if err != nil {
+			return err
+		}
+		done++
+	}
+	drawTUI(w, tps, "done")
+	return nil
+}
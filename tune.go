@@ -0,0 +1,148 @@
+//go:build !headless
+
+package main
+
+/// @file tune.go
+/// @brief On-screen panel to change breed/starve parameters while running.
+/// @details `P` or the Tune button toggles the panel. Up/Down selects a
+/// parameter and Left/Right decreases or increases it by one; without a
+/// keyboard, e.g. on a touch screen, clicking or tapping the `[-]` and `[+]`
+/// of a row does the same. Every change goes through
+/// `applyLiveConfig()` under `worldMu`, like PUT /config, so it lands
+/// between two ticks and is validated first; in compare mode it applies to
+/// world A.
+///
+/// Safe to change live: the breed and starve timers here (and everything
+/// else PUT /config accepts). A change only affects timers set from then
+/// on, when a creature is born, breeds or eats; creatures keep the timers
+/// they already have. The initial populations, stacking, zones, random
+/// source, scheduler and grid size are only read when the world is built or
+/// the program starts, so they need a reset (POST /reset) or a restart.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// / @brief The tunable parameters, in panel order.
+var tuneParams = []string{"fishBreed", "sharkBreed", "sharkStarve"}
+
+var showTune bool = false
+var tuneSel int = 0
+
+// / @brief Parameters as last read under `worldMu`, for drawing the panel.
+var tuneShown liveConfig
+
+// / @brief Value of tunable parameter `i` in `c`.
+func tuneValue(c liveConfig, i int) int {
+	switch i {
+	case 0:
+		return c.FishBreed
+	case 1:
+		return c.SharkBreed
+	}
+	return c.SharkStarve
+}
+
+// / @brief Patch setting tunable parameter `i` to `v`.
+func tunePatch(i, v int) liveConfigPatch {
+	var p liveConfigPatch
+	switch i {
+	case 0:
+		p.FishBreed = &v
+	case 1:
+		p.SharkBreed = &v
+	default:
+		p.SharkStarve = &v
+	}
+	return p
+}
+
+// / @brief Debug font cell size in window pixels.
+const charW, lineH = 6, 16
+
+// / @brief Columns of the `[-]` and `[+]` of each panel row.
+const tuneMinusCol, tunePlusCol = 21, 25
+
+// / @brief Window position of the top left corner of the panel text.
+func tuneOrigin() (x, y int) {
+	return 4, win.winH - barHeight - lineH*(len(tuneParams)+1) - 4
+}
+
+// / @brief Change of the row under a click or tap at `(px, py)`.
+// / @return row The row hit, -1 for none.
+// / @return delta -1 or +1 on its `[-]` or `[+]`, else 0.
+func tuneHit(px, py int) (row, delta int) {
+	x, y := tuneOrigin()
+	if py < y || py >= y+lineH*len(tuneParams) {
+		return -1, 0
+	}
+	col := (px - x) / charW
+	switch {
+	case col >= tuneMinusCol && col < tuneMinusCol+3:
+		delta = -1
+	case col >= tunePlusCol && col < tunePlusCol+3:
+		delta = 1
+	}
+	return (py - y) / lineH, delta
+}
+
+// / @brief Handle the panel keys, clicks and taps; called once per frame
+// / without `worldMu`.
+func updateTune() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		showTune = !showTune
+	}
+	if !showTune {
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
+		tuneSel = (tuneSel + len(tuneParams) - 1) % len(tuneParams)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
+		tuneSel = (tuneSel + 1) % len(tuneParams)
+	}
+	delta := 0
+	if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+		delta--
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
+		delta++
+	}
+	if px, py, ok := pointerPressed(); ok {
+		if row, d := tuneHit(px, py); row >= 0 {
+			tuneSel, delta = row, d
+		}
+	}
+
+	worldMu.Lock()
+	defer worldMu.Unlock()
+	if delta != 0 {
+		v := tuneValue(currentLiveConfig(), tuneSel) + delta
+		// a rejected value (e.g. below 0) simply leaves the old one
+		applyLiveConfig(tunePatch(tuneSel, v))
+	}
+	tuneShown = currentLiveConfig()
+}
+
+// / @brief Draw the panel (if shown) at the bottom left of the window.
+func drawTune(window *ebiten.Image) {
+	if !showTune {
+		return
+	}
+	var b strings.Builder
+	for i, name := range tuneParams {
+		mark := " "
+		if i == tuneSel {
+			mark = ">"
+		}
+		fmt.Fprintf(&b, "%s %-12s %4d  [-] [+]\n", mark, name, tuneValue(tuneShown, i))
+	}
+	b.WriteString("Up/Down select, Left/Right change")
+	x, y := tuneOrigin()
+	ebitenutil.DebugPrintAt(window, b.String(), x, y)
+}
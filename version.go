@@ -0,0 +1,37 @@
+package main
+
+/// @file version.go
+/// @brief Build and runtime information for bug reports.
+/// @details `wator version` (or `-version`) prints one `key=value` per
+/// line: the module version, Go version, GOMAXPROCS, NumCPU, the grid
+/// constants and the compiled-in default of every flag as
+/// `default.<flag>=<value>`. Nothing is initialized and no window opens.
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Write the version report to `w`.
+func printVersion(w io.Writer) {
+	version := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version = info.Main.Version
+	}
+	fmt.Fprintf(w, "version=%s\n", version)
+	fmt.Fprintf(w, "go_version=%s\n", runtime.Version())
+	fmt.Fprintf(w, "os_arch=%s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(w, "gomaxprocs=%d\n", runtime.GOMAXPROCS(0))
+	fmt.Fprintf(w, "num_cpu=%d\n", runtime.NumCPU())
+	fmt.Fprintf(w, "width=%d\n", wator.Width)
+	fmt.Fprintf(w, "height=%d\n", wator.Height)
+	fmt.Fprintf(w, "scale=%d\n", scale)
+	flag.VisitAll(func(f *flag.Flag) {
+		fmt.Fprintf(w, "default.%s=%s\n", f.Name, f.DefValue)
+	})
+}
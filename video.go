@@ -0,0 +1,99 @@
+package main
+
+/// @file video.go
+/// @brief `-record`: stream the run into a video via ffmpeg.
+/// @details `-record out.mp4` starts `ffmpeg` (from `$PATH`) and pipes it
+/// one raw RGBA frame per simulation tick, hooked into `recordTick()` like
+/// `-export-frames`, beginning with the initial world. Nothing is kept in
+/// memory or on disk besides the video, so long runs cost no more than
+/// short ones. ffmpeg picks the codec from the file name; the frames play
+/// at `-record-fps` per second (default 30) and every cell is drawn as a
+/// `-record-scale` x `-record-scale` block (default 2) in the palette
+/// colors. Odd frame sizes are padded by one pixel, since the common video
+/// formats need even sizes. If ffmpeg exits early its error is reported,
+/// recording stops and the run goes on.
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+var videoFPS int = 30
+var videoScale int = 2
+
+var videoCmd *exec.Cmd
+var videoPipe io.WriteCloser
+var videoBuf []byte    // one RGBA frame, reused
+var videoTick int = -1 // last tick written
+
+// / @brief Start ffmpeg writing to `path` and send it the current grid.
+// / @return error Any error starting ffmpeg.
+func startVideo(path string) error {
+	w, h := wator.Width*videoScale, wator.Height*videoScale
+	cmd := exec.Command("ffmpeg", "-y", "-loglevel", "error",
+		"-f", "rawvideo", "-pix_fmt", "rgba", "-s", fmt.Sprintf("%dx%d", w, h),
+		"-r", fmt.Sprint(videoFPS), "-i", "-",
+		"-vf", "pad=ceil(iw/2)*2:ceil(ih/2)*2", "-pix_fmt", "yuv420p", path)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	pipe, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("record: %v", err)
+	}
+	videoCmd, videoPipe = cmd, pipe
+	videoBuf = make([]byte, 4*w*h)
+	writeVideoFrame()
+	return nil
+}
+
+// / @brief Send the grid of the current tick to ffmpeg, once per tick.
+func writeVideoFrame() {
+	if videoPipe == nil || wator.Tick == videoTick {
+		return
+	}
+	videoTick = wator.Tick
+	colors := [3]color.RGBA{pal.bg, pal.fish, pal.shark}
+	stride := 4 * wator.Width * videoScale
+	for y := 0; y < wator.Height; y++ {
+		row := videoBuf[y*videoScale*stride:]
+		for x := 0; x < wator.Width; x++ {
+			c := colors[wator.Grid[y*wator.Width+x]]
+			for i := 0; i < videoScale; i++ {
+				p := row[4*(x*videoScale+i):]
+				p[0], p[1], p[2], p[3] = c.R, c.G, c.B, c.A
+			}
+		}
+		for j := 1; j < videoScale; j++ {
+			copy(row[j*stride:(j+1)*stride], row[:stride])
+		}
+	}
+	if _, err := videoPipe.Write(videoBuf); err != nil {
+		fmt.Fprintf(os.Stderr, "record: ffmpeg stopped reading (%v); no more frames are recorded\n", err)
+		if err := closeVideo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+// / @brief Finish the video: close ffmpeg's input and wait for it to exit.
+// / @return error The error ffmpeg exited with.
+func closeVideo() error {
+	if videoCmd == nil {
+		return nil
+	}
+	videoPipe.Close()
+	err := videoCmd.Wait()
+	videoCmd, videoPipe = nil, nil
+	if err != nil {
+		return fmt.Errorf("record: ffmpeg: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+//go:build !headless
+
+package main
+
+/// @file view.go
+/// @brief Runtime zoom and pan of the rendered grid.
+/// @details The simulation grid is drawn 1:1 (times `scale`) into an
+/// offscreen image, which `display()` then copies to the window through the
+/// view transform kept here. Zooming and panning therefore never touch the
+/// simulation resolution. Controls: mouse wheel zooms around the cursor,
+/// Shift + left drag pans, and `0` resets the view.
+///
+/// The window is resizable. Whatever its size, the content (the grid, or
+/// both grids side by side in compare mode) is scaled uniformly to fit and
+/// centered, with the leftover margins drawn as letterbox bars. That fit
+/// replaces the fixed window scale of 2 used before; `scale` itself still
+/// sets the resolution of the offscreen image. The bottom `barHeight`
+/// pixels of the window are reserved for the control bar (controls.go) and
+/// never show content.
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const minZoom = 1.0
+const maxZoom = 32.0
+
+// / @brief Current view transform.
+// / @details `offX`/`offY` is the grid-pixel position shown at the top-left
+// / corner of the window; `zoom` is the number of window pixels per grid pixel.
+type viewport struct {
+	zoom       float64
+	offX, offY float64
+
+	dragging     bool
+	lastX, lastY float64
+}
+
+var view = viewport{zoom: 1}
+
+// / @brief How the content is fitted into the window.
+// / @details `fit` is the number of window pixels per content pixel and
+// / `lbX`/`lbY` are the letterbox margins on each side. Updated by `Layout()`.
+type windowFit struct {
+	winW, winH int
+	fit        float64
+	lbX, lbY   float64
+}
+
+var win = windowFit{fit: 2}
+
+// / @brief Size of the unscaled content: one grid, or two in compare mode.
+func contentSize() (int, int) {
+	w := wator.Width * scale
+	if compareMode {
+		w *= 2
+	}
+	return w, wator.Height * scale
+}
+
+// / @brief Recompute the fit for a window of `ow` x `oh` pixels.
+// / @details Keeps the aspect ratio: the content is scaled by the smaller of
+// / the two ratios and centered along the other axis, in the area above the
+// / control bar.
+func fitWindow(ow, oh int) {
+	cw, ch := contentSize()
+	if ow <= 0 || oh <= barHeight {
+		return
+	}
+	areaH := oh - barHeight
+	f := math.Min(float64(ow)/float64(cw), float64(areaH)/float64(ch))
+	win = windowFit{
+		winW: ow, winH: oh,
+		fit: f,
+		lbX: (float64(ow) - f*float64(cw)) / 2,
+		lbY: (float64(areaH) - f*float64(ch)) / 2,
+	}
+}
+
+// / @brief Map a window position to unscaled content coordinates.
+// / @details In compare mode both worlds share the view, so positions over
+// / world B map onto the same coordinates as over world A.
+func toContent(sx, sy int) (float64, float64) {
+	cx := (float64(sx) - win.lbX) / win.fit
+	cy := (float64(sy) - win.lbY) / win.fit
+	if compareMode && cx >= float64(wator.Width*scale) {
+		cx -= float64(wator.Width * scale)
+	}
+	return cx, cy
+}
+
+// / @brief Map an unscaled content position to the window, ignoring zoom.
+// / @details Used to place text such as labels relative to the content.
+func contentToWindow(cx, cy int) (int, int) {
+	return int(win.lbX + float64(cx)*win.fit), int(win.lbY + float64(cy)*win.fit)
+}
+
+// / @brief Apply mouse/keyboard input to the view transform.
+// / @details Called once per tick from `frame()`.
+func updateView() {
+	cx, cy := toContent(ebiten.CursorPosition())
+
+	if _, dy := ebiten.Wheel(); dy != 0 {
+		// keep the grid point under the cursor fixed while zooming
+		gx := view.offX + cx/view.zoom
+		gy := view.offY + cy/view.zoom
+		view.zoom = math.Max(minZoom, math.Min(maxZoom, view.zoom*math.Pow(1.1, dy)))
+		view.offX = gx - cx/view.zoom
+		view.offY = gy - cy/view.zoom
+	}
+
+	panning := ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) &&
+		(ebiten.IsKeyPressed(ebiten.KeyShift) || ebiten.IsKeyPressed(ebiten.KeyControl))
+	if panning {
+		if view.dragging {
+			view.offX -= (cx - view.lastX) / view.zoom
+			view.offY -= (cy - view.lastY) / view.zoom
+		}
+		view.lastX, view.lastY = cx, cy
+	}
+	view.dragging = panning
+
+	if ebiten.IsKeyPressed(ebiten.Key0) {
+		view = viewport{zoom: 1}
+	}
+
+	clampView()
+}
+
+// / @brief Keep the visible region inside the grid.
+func clampView() {
+	maxX := float64(wator.Width*scale) - float64(wator.Width*scale)/view.zoom
+	maxY := float64(wator.Height*scale) - float64(wator.Height*scale)/view.zoom
+	view.offX = math.Max(0, math.Min(maxX, view.offX))
+	view.offY = math.Max(0, math.Min(maxY, view.offY))
+}
+
+// / @brief Map a window position to the grid cell drawn there.
+// / @details Anything that turns mouse positions into grid cells (e.g.
+// / painting creatures) must go through this so it stays consistent with the
+// / current zoom, pan and window fit.
+// / @param sx Window x coordinate.
+// / @param sy Window y coordinate.
+// / @return gx, gy The grid cell under the position.
+// / @return ok False if the position lies outside the grid.
+func screenToGrid(sx, sy int) (gx, gy int, ok bool) {
+	cx, cy := toContent(sx, sy)
+	if cx < 0 || cy < 0 || cx >= float64(wator.Width*scale) || cy >= float64(wator.Height*scale) {
+		// in the letterbox or the control bar, even if zoomed in
+		return 0, 0, false
+	}
+	gy = int(math.Floor((view.offY + cy/view.zoom) / float64(scale)))
+	shift := 0.0
+	if wator.Topology == wator.TopoHex && gy%2 == 1 {
+		// odd hex rows are drawn half a cell to the right
+		shift = 0.5
+	}
+	gx = int(math.Floor((view.offX+cx/view.zoom)/float64(scale) - shift))
+	if gx < 0 || gx >= wator.Width || gy < 0 || gy >= wator.Height {
+		return 0, 0, false
+	}
+	return gx, gy, true
+}
+
+// / @brief Draw option that places the offscreen world image in the window.
+func viewOptions() *ebiten.DrawImageOptions {
+	return viewOptionsAt(0)
+}
+
+// / @brief Like `viewOptions`, for a world drawn `dx` content pixels to the right.
+func viewOptionsAt(dx float64) *ebiten.DrawImageOptions {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-view.offX, -view.offY)
+	op.GeoM.Scale(view.zoom, view.zoom)
+	op.GeoM.Translate(dx, 0)
+	op.GeoM.Scale(win.fit, win.fit)
+	op.GeoM.Translate(win.lbX, win.lbY)
+	op.Filter = ebiten.FilterNearest
+	return op
+}
+
+// / @brief Paint the letterbox margins.
+// / @details Also hides whatever a zoomed world image draws past the content
+// / area, since Ebiten cannot clip drawing to a sub-image.
+func drawLetterbox(window *ebiten.Image) {
+	cw, ch := contentSize()
+	w, h := float32(win.winW), float32(win.winH)
+	left, top := float32(win.lbX), float32(win.lbY)
+	right := left + float32(win.fit*float64(cw))
+	bottom := top + float32(win.fit*float64(ch))
+	vector.FillRect(window, 0, 0, w, top, color.Black, false)
+	vector.FillRect(window, 0, bottom, w, h-bottom, color.Black, false)
+	vector.FillRect(window, 0, 0, left, h, color.Black, false)
+	vector.FillRect(window, right, 0, w-right, h, color.Black, false)
+}
+
+// / @brief Ebiten game driving `frame()` and `drawFrame()` in a resizable
+// / window.
+// / @details Implements Ebitengine v2's `ebiten.Game`: Ebiten calls
+// / `Update` 60 times per second and `Draw` once per frame, and `frame()`
+// / runs the ticks due at `simTPS` in each `Update`, so the simulation rate
+// / does not depend on the frame rate.
+type game struct{}
+
+// / @brief Handle input and advance the simulation by the ticks due.
+func (game) Update() error {
+	return frame()
+}
+
+// / @brief Draw one frame.
+func (game) Draw(screen *ebiten.Image) {
+	drawFrame(screen)
+}
+
+// / @brief Use the whole window as the screen and refit the content to it.
+func (game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	fitWindow(outsideWidth, outsideHeight)
+	return outsideWidth, outsideHeight
+}
+
+// / @brief Draw the world image `img` `dx` content pixels to the right.
+// / @details On the square grid a single draw through `viewOptionsAt()`;
+// / on the hex grid row by row, with odd rows shifted half a cell.
+func drawWorld(window, img *ebiten.Image, dx float64) {
+	if wator.Topology != wator.TopoHex {
+		window.DrawImage(img, viewOptionsAt(dx))
+		return
+	}
+	geo := viewOptionsAt(dx).GeoM
+	for y := 0; y < wator.Height; y++ {
+		shift := 0.0
+		if y%2 == 1 {
+			shift = float64(scale) / 2
+		}
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(shift, float64(y*scale))
+		op.GeoM.Concat(geo)
+		op.Filter = ebiten.FilterNearest
+		row := img.SubImage(image.Rect(0, y*scale, wator.Width*scale, (y+1)*scale)).(*ebiten.Image)
+		window.DrawImage(row, op)
+	}
+}
@@ -1,510 +1,549 @@
-package main
-
-/// @file wator.go
-/// @brief Wa-Tor predator-prey simulation using Ebiten (Go).
-/// @details This file implements the Wa-Tor simulation: fish and sharks
-/// interact on a toroidal grid. The simulation supports a multithreaded
-/// update step that partitions the grid into tiles and uses per-tile
-/// mutexes to protect concurrent writes. Benchmark helper functions are
-/// included to measure performance with different `threads` settings.
-
-import (
-	"fmt"
-	"image/color"
-	"log"
-	"math"
-	"math/rand"
-	"os"
-	"runtime"
-	"sync"
-	"time"
-
-	"github.com/hajimehoshi/ebiten"
-)
-
-// / @brief Simulation settings: initial counts and timers.
-var numShark int = 4000
-var numFish int = 10000
-var fishBreed int = 3   // ticks before fish can breed
-var sharkBreed int = 8  // ticks before shark can breed
-var sharkStarve int = 3 // ticks a shark can go without eating
-var threads int = 4     // number of worker goroutines
-
-const width = 400
-const height = 400
-
-// / @brief Grid values: 0 empty, 1 fish, 2 shark
-var grid [width][height]uint8 = [width][height]uint8{}
-var buffer [width][height]uint8 = [width][height]uint8{}
-
-var breedTimer [width][height]int
-var bufferBreed [width][height]int
-
-var starveTimer [width][height]int
-var bufferStarve [width][height]int
-
-const scale int = 1
-
-var bg color.Color = color.RGBA{69, 145, 196, 255}
-var fish color.Color = color.RGBA{255, 230, 120, 255}
-var shark color.Color = color.RGBA{200, 50, 50, 255}
-
-var count int = 0
-
-// / @brief Returns the current number of fish on the grid.
-// / @return int Number of cells containing a fish.
-func countFish() int {
-	cnt := 0
-	for x := 0; x < width; x++ {
-		for y := 0; y < height; y++ {
-			if grid[x][y] == 1 {
-				cnt++
-			}
-		}
-	}
-	return cnt
-}
-
-// / @brief Compute the next simulation tick.
-// / @details update() builds the next world state in `buffer` and then
-// / swaps buffers into `grid`. The function partitions the grid into tiles
-// / and launches goroutines to process tiles in parallel. Per-tile
-// / mutexes are used to protect concurrent writes into `buffer` and timer
-// / arrays. Fish try to move/breed into empty neighbors; sharks try to eat
-// / adjacent fish first, otherwise move or possibly starve.
-// / @return error Always returns nil (placeholder for potential error handling).
-func update() error {
-	// Clear next-state buffers
-	for x := 0; x < width; x++ {
-		for y := 0; y < height; y++ {
-			buffer[x][y] = 0
-			bufferBreed[x][y] = 0
-			bufferStarve[x][y] = 0
-		}
-	}
-
-	var wg sync.WaitGroup
-
-	innerWidth := width
-	if threads > innerWidth {
-		threads = innerWidth
-	}
-
-	// Choose a tile grid close to a square of `threads` workers.
-	tileCols := int(math.Sqrt(float64(threads)))
-	if tileCols <= 0 {
-		tileCols = 1
-	}
-	tileRows := (threads + tileCols - 1) / tileCols
-	if tileRows <= 0 {
-		tileRows = 1
-	}
-
-	tileW := (width + tileCols - 1) / tileCols
-	tileH := (height + tileRows - 1) / tileRows
-
-	// per-tile mutexes to protect writes into buffer/breed/starve
-	tileMutex := make([][]sync.Mutex, tileCols)
-	for i := 0; i < tileCols; i++ {
-		tileMutex[i] = make([]sync.Mutex, tileRows)
-	}
-
-	// helpers to lock/unlock either one tile or two tiles in deterministic order
-	lockTwo := func(ax, ay, bx, by int) {
-		aID := ax*tileRows + ay
-		bID := bx*tileRows + by
-		if aID == bID {
-			tileMutex[ax][ay].Lock()
-			return
-		}
-		if aID < bID {
-			tileMutex[ax][ay].Lock()
-			tileMutex[bx][by].Lock()
-		} else {
-			tileMutex[bx][by].Lock()
-			tileMutex[ax][ay].Lock()
-		}
-	}
-	unlockTwo := func(ax, ay, bx, by int) {
-		aID := ax*tileRows + ay
-		bID := bx*tileRows + by
-		if aID == bID {
-			tileMutex[ax][ay].Unlock()
-			return
-		}
-		if aID < bID {
-			tileMutex[bx][by].Unlock()
-			tileMutex[ax][ay].Unlock()
-		} else {
-			tileMutex[ax][ay].Unlock()
-			tileMutex[bx][by].Unlock()
-		}
-	}
-
-	// Launch one goroutine per tile (or group tiles to match threads)
-	for tx := 0; tx < tileCols; tx++ {
-		for ty := 0; ty < tileRows; ty++ {
-			startX := tx * tileW
-			endX := startX + tileW
-			if endX > width {
-				endX = width
-			}
-			startY := ty * tileH
-			endY := startY + tileH
-			if endY > height {
-				endY = height
-			}
-			// Skip empty tiles
-			if startX >= endX || startY >= endY {
-				continue
-			}
-
-			wg.Add(1)
-			go func(sx, ex, sy, ey, ttx, tty int) {
-				defer wg.Done()
-
-				for x := sx; x < ex; x++ {
-					for y := sy; y < ey; y++ {
-						// Fish behavior
-						if grid[x][y] == 1 {
-							directions := [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
-							rand.Shuffle(len(directions), func(i, j int) {
-								directions[i], directions[j] = directions[j], directions[i]
-							})
-
-							moved := false
-							newBreed := breedTimer[x][y] - 1
-
-							for _, dir := range directions {
-								nx := (x + dir[0] + width) % width
-								ny := (y + dir[1] + height) % height
-
-								ox := nx / tileW
-								oy := ny / tileH
-								sOx := x / tileW
-								sOy := y / tileH
-
-								// lock target tile and source tile (deterministic order)
-								lockTwo(sOx, sOy, ox, oy)
-
-								if grid[nx][ny] == 0 && buffer[nx][ny] == 0 {
-									if newBreed <= 0 {
-										// breed: leave offspring and reset parent timer
-										if buffer[x][y] == 0 {
-											buffer[x][y] = 1
-											bufferBreed[x][y] = fishBreed
-										}
-										buffer[nx][ny] = 1
-										bufferBreed[nx][ny] = fishBreed
-									} else {
-										// move with decremented timer
-										buffer[nx][ny] = 1
-										bufferBreed[nx][ny] = newBreed
-									}
-									moved = true
-								}
-
-								unlockTwo(sOx, sOy, ox, oy)
-
-								if moved {
-									break
-								}
-							}
-
-							if !moved {
-								sOx := x / tileW
-								sOy := y / tileH
-								// lock only source tile to write stay-in-place
-								tileMutex[sOx][sOy].Lock()
-								if buffer[x][y] == 0 {
-									buffer[x][y] = 1
-									if newBreed < 0 {
-										newBreed = 0
-									}
-									bufferBreed[x][y] = newBreed
-								}
-								tileMutex[sOx][sOy].Unlock()
-							}
-
-							// Shark behavior
-						} else if grid[x][y] == 2 {
-							directions := [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
-							rand.Shuffle(len(directions), func(i, j int) {
-								directions[i], directions[j] = directions[j], directions[i]
-							})
-
-							moved := false
-							newBreed := breedTimer[x][y] - 1
-							newStarve := starveTimer[x][y] - 1
-
-							// Try to eat a fish first
-							for _, dir := range directions {
-								nx := (x + dir[0] + width) % width
-								ny := (y + dir[1] + height) % height
-
-								ox := nx / tileW
-								oy := ny / tileH
-								sOx := x / tileW
-								sOy := y / tileH
-
-								// lock source and target tiles
-								lockTwo(sOx, sOy, ox, oy)
-
-								if grid[nx][ny] == 1 && buffer[nx][ny] == 0 {
-									// eat: reset starvation and clear eaten fish
-									newStarve = sharkStarve
-									// mark eaten fish in original grid (reading other goroutines still read original grid)
-									grid[nx][ny] = 0
-
-									if newBreed <= 0 {
-										if buffer[x][y] == 0 {
-											buffer[x][y] = 2
-											bufferBreed[x][y] = sharkBreed
-											bufferStarve[x][y] = sharkStarve
-										}
-										buffer[nx][ny] = 2
-										bufferBreed[nx][ny] = sharkBreed
-										bufferStarve[nx][ny] = newStarve
-									} else {
-										buffer[nx][ny] = 2
-										bufferBreed[nx][ny] = newBreed
-										bufferStarve[nx][ny] = newStarve
-									}
-									moved = true
-								}
-
-								unlockTwo(sOx, sOy, ox, oy)
-
-								if moved {
-									break
-								}
-							}
-
-							// If no fish eaten, try empty neighbor
-							if !moved {
-								for _, dir := range directions {
-									nx := (x + dir[0] + width) % width
-									ny := (y + dir[1] + height) % height
-
-									ox := nx / tileW
-									oy := ny / tileH
-									sOx := x / tileW
-									sOy := y / tileH
-
-									lockTwo(sOx, sOy, ox, oy)
-
-									if grid[nx][ny] == 0 && buffer[nx][ny] == 0 {
-										// if starved, shark dies (do not write)
-										if newStarve <= 0 {
-											moved = true
-											// nothing to write
-										} else if newBreed <= 0 {
-											// breed: leave newborn and reset parent
-											if buffer[x][y] == 0 {
-												buffer[x][y] = 2
-												bufferBreed[x][y] = sharkBreed
-												bufferStarve[x][y] = sharkStarve
-											}
-											buffer[nx][ny] = 2
-											bufferBreed[nx][ny] = sharkBreed
-											bufferStarve[nx][ny] = newStarve
-										} else {
-											// normal move
-											buffer[nx][ny] = 2
-											bufferBreed[nx][ny] = newBreed
-											bufferStarve[nx][ny] = newStarve
-										}
-										moved = true
-									}
-
-									unlockTwo(sOx, sOy, ox, oy)
-
-									if moved {
-										break
-									}
-								}
-							}
-
-							if !moved {
-								sOx := x / tileW
-								sOy := y / tileH
-								// stay or die if starved
-								if newStarve <= 0 {
-									// die
-								} else {
-									tileMutex[sOx][sOy].Lock()
-									if buffer[x][y] == 0 {
-										buffer[x][y] = 2
-										if newBreed < 0 {
-											newBreed = 0
-										}
-										bufferBreed[x][y] = newBreed
-										bufferStarve[x][y] = newStarve
-									}
-									tileMutex[sOx][sOy].Unlock()
-								}
-							}
-						}
-					}
-				}
-			}(startX, endX, startY, endY, tx, ty)
-		}
-	}
-
-	wg.Wait()
-
-	// Swap grids and timer arrays
-	temp := buffer
-	buffer = grid
-	grid = temp
-
-	tempBreed := bufferBreed
-	bufferBreed = breedTimer
-	breedTimer = tempBreed
-
-	tempStarve := bufferStarve
-	bufferStarve = starveTimer
-	starveTimer = tempStarve
-
-	//fmt.Printf("Fish: %d\n", countFish())
-
-	return nil
-}
-
-// / @brief Render the current `grid` into the provided Ebiten image.
-// / @param window Pointer to the Ebiten image used as the drawing surface.
-func display(window *ebiten.Image) {
-	window.Fill(bg)
-
-	for x := 0; x < width; x++ {
-		for y := 0; y < height; y++ {
-			for i := 0; i < scale; i++ {
-				for j := 0; j < scale; j++ {
-					switch grid[x][y] {
-					case 1:
-						window.Set(x*scale+i, y*scale+j, fish)
-					case 2:
-						window.Set(x*scale+i, y*scale+j, shark)
-					}
-				}
-			}
-		}
-	}
-}
-
-// / @brief Per-frame handler passed to Ebiten's run loop.
-// / @details Calls `update()` intermittently (controlled by `count`) and then
-// / draws the world via `display`.
-// / @param window Pointer to the Ebiten image for the frame.
-// / @return error Propagates any error coming from `update()`.
-func frame(window *ebiten.Image) error {
-	count++
-	var err error = nil
-	if count == 1 {
-		err = update()
-		count = 0
-	}
-	if !ebiten.IsDrawingSkipped() {
-		display(window)
-	}
-
-	return err
-}
-
-// / @brief Initialize the world grid and timers.
-// / @details Clears the grid and places `numFish` fish and `numShark` sharks
-// / at random, using fixed breed/starve timers defined by `fishBreed` and
-// / `sharkBreed`/`sharkStarve`.
-func initWorld() {
-	// Clear everything
-	for x := 0; x < width; x++ {
-		for y := 0; y < height; y++ {
-			grid[x][y] = 0
-			breedTimer[x][y] = 0
-			starveTimer[x][y] = 0
-		}
-	}
-
-	// Place initial fish
-	for i := 0; i < numFish; i++ {
-		x := rand.Intn(width)
-		y := rand.Intn(height)
-		if grid[x][y] == 0 {
-			grid[x][y] = 1
-			breedTimer[x][y] = fishBreed
-		} else {
-			i--
-		}
-	}
-
-	// Place initial sharks
-	for i := 0; i < numShark; i++ {
-		x := rand.Intn(width)
-		y := rand.Intn(height)
-		if grid[x][y] == 0 {
-			grid[x][y] = 2
-			breedTimer[x][y] = sharkBreed
-			starveTimer[x][y] = sharkStarve
-		} else {
-			i--
-		}
-	}
-}
-
-// / @brief Run a single benchmark of the simulation for `steps` ticks.
-// / @param steps Number of simulation ticks to execute.
-// / @param thr Number of worker threads (goroutines) to use.
-// / @return time.Duration The elapsed time taken to perform `steps` updates.
-func runSingleBenchmark(steps int, thr int) time.Duration {
-	threads = thr
-	runtime.GOMAXPROCS(threads)
-
-	// fixed seed so all runs start with same initial world
-	rand.Seed(42)
-	initWorld()
-
-	start := time.Now()
-	for i := 0; i < steps; i++ {
-		update()
-	}
-	elapsed := time.Since(start)
-
-	return elapsed
-}
-
-// / @brief Run a set of benchmarks across multiple thread counts and print CSV results.
-func runBenchmarks() {
-	steps := 1000 // or 500 / 1000, just keep it consistent across runs
-
-	threadConfigs := []int{1, 2, 4, 8}
-	fmt.Printf("threads,steps,time_seconds\n")
-	for _, thr := range threadConfigs {
-		dur := runSingleBenchmark(steps, thr)
-		seconds := dur.Seconds()
-		fmt.Printf("%d,%d,%.6f\n", thr, steps, seconds)
-	}
-}
-
-// / @brief Program entry point.
-// / @details If the first command line argument equals "bench", run the
-// / benchmark mode; otherwise run the interactive Ebiten graphical mode.
-func main() {
-	rand.Seed(time.Now().UnixNano())
-
-	// Simple arg check: if first arg is "bench", run benchmark mode
-	if len(os.Args) > 1 && os.Args[1] == "bench" {
-		runBenchmarks()
-		return
-	}
-
-	// ==== normal graphical mode ====
-	runtime.GOMAXPROCS(threads)
-
-	initWorld()
-	fmt.Printf("Initial fish: %d\n", countFish())
-
-	if err := ebiten.Run(frame, width, height, 2, "Wa-Tor"); err != nil {
-		log.Fatal(err)
-	}
-}
-
+package main
+
+/// @file wator.go
+/// @brief Wa-Tor predator-prey simulation using Ebiten (Go).
+/// @details The simulation itself is the `wator` package (see
+/// wator/wator.go); this file is the program around it: the flags, the
+/// Ebiten frame loop and the per-run outputs. Benchmark helper functions
+/// are included to measure performance with different `wator.Threads` settings.
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Seed of the global random source, from `-seed` or the clock.
+var runSeed int64 = 0
+
+const scale int = 1
+
+// / @brief Grid size requested by `-width`/`-height` or the config file,
+// / applied by `wator.SetSize()` before the world is built.
+var gridWidth int = wator.Width
+var gridHeight int = wator.Height
+
+// / @brief Run the simulation in a background goroutine (see sim.go).
+var async bool = false
+
+// / @brief Highest `-sim-tps` and `-headless-tps`; a tick interval of a
+// / microsecond is as short as a ticker reliably delivers.
+const maxTPS = 1e6
+
+// / @brief `wator.ValidateConfig()` plus the settings of the program around it.
+// / @return error Describes the first invalid setting, nil if all are usable.
+func validateSettings() error {
+	if err := wator.ValidateConfig(); err != nil {
+		return err
+	}
+	// written so that NaN fails too
+	if !(simTPS >= 0 && simTPS <= maxTPS) {
+		return fmt.Errorf("ticks per second must be between 0 and %g, got %g", float64(maxTPS), simTPS)
+	}
+	if headlessTPS < 0 || headlessTPS > maxTPS {
+		return fmt.Errorf("headless-tps must be between 0 and %g, got %d", float64(maxTPS), headlessTPS)
+	}
+	return nil
+}
+
+// / @brief Flush and close the per-run outputs, logging any errors.
+// / @param summaryPath `-summary-png` destination, or "" for none.
+func closeOutputs(summaryPath string) {
+	if err := closeNDJSON(); err != nil {
+		log.Print(err)
+	}
+	if err := closeGIF(); err != nil {
+		log.Print(err)
+	}
+	if err := closeTimers(); err != nil {
+		log.Print(err)
+	}
+	if err := closeVideo(); err != nil {
+		log.Print(err)
+	}
+	if err := closeReplayOut(); err != nil {
+		log.Print(err)
+	}
+	if summaryPath != "" {
+		if err := wator.WriteSummaryPNG(summaryPath); err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+// / @brief Program entry point.
+// / @details Flags are parsed first; if the first remaining argument equals
+// / "bench", run the benchmark mode; otherwise run the interactive Ebiten
+// / graphical mode.
+func main() {
+	configPath := flag.String("config", "", "file with simulation settings: JSON, or YAML/TOML when named .yaml, .yml or .toml; flags given on the command line win")
+	flag.StringVar(&themeName, "theme", themeName, "color theme: "+strings.Join(themeNames(), ", "))
+	flag.StringVar(&bgHex, "bg-color", "", "background color as hex RRGGBB (overrides theme)")
+	flag.StringVar(&fishHex, "fish-color", "", "fish color as hex RRGGBB (overrides theme)")
+	flag.StringVar(&sharkHex, "shark-color", "", "shark color as hex RRGGBB (overrides theme)")
+	flag.IntVar(&wator.SharkVision, "shark-vision", wator.SharkVision, "sharks steer toward the nearest fish within this Manhattan distance (1 = neighbors only)")
+	hunt := flag.String("shark-hunt", wator.HuntRandom, "shark hunting strategy: random or greedy")
+	rngName := flag.String("rng", wator.RNGMath, "random source of the update step: math (math/rand), pcg (one PCG32 per tile), tile (one math/rand generator per tile) or cell (a stream per creature from seed, tick and position)")
+	extinctMode := flag.String("on-extinct", wator.ExtinctStop, "when every creature has died: stop or reset")
+	flag.BoolVar(&wator.Sparse, "sparse", false, "visit only occupied cells (faster on sparse worlds); short for -engine sparse")
+	engine := flag.String("engine", wator.EngineDense, "cell visiting: dense (scan every cell), sparse (only occupied cells) or auto (switch by the share of occupied cells)")
+	flag.BoolVar(&wator.Deterministic, "deterministic", false, "try neighbors in fixed N, E, S, W order instead of a random one")
+	flag.BoolVar(&wator.RandomizeInitialTimers, "randomize-initial-timers", false, "start creatures with random timers in [1, full] instead of all in sync")
+	flag.IntVar(&wator.FishBreedJitter, "fish-breed-jitter", 0, "randomize a fish's breed timer after breeding by up to +/- N ticks")
+	flag.IntVar(&wator.SharkBreedJitter, "shark-breed-jitter", 0, "randomize a shark's breed timer after breeding by up to +/- N ticks")
+	flag.IntVar(&gridWidth, "width", gridWidth, "grid width in cells")
+	flag.IntVar(&gridHeight, "height", gridHeight, "grid height in cells")
+	flag.IntVar(&wator.NumFish, "fish", wator.NumFish, "initial number of fish")
+	flag.IntVar(&wator.NumShark, "sharks", wator.NumShark, "initial number of sharks")
+	flag.IntVar(&wator.FishBreed, "fish-breed", wator.FishBreed, "ticks before a fish can breed")
+	flag.IntVar(&wator.SharkBreed, "shark-breed", wator.SharkBreed, "ticks before a shark can breed")
+	flag.IntVar(&wator.SharkStarve, "shark-starve", wator.SharkStarve, "ticks a shark survives without eating")
+	flag.IntVar(&wator.Threads, "threads", wator.Threads, "worker goroutines of the parallel update")
+	flag.IntVar(&wator.FishStarve, "fish-starve", wator.FishStarve, "ticks a fish survives without breeding (0 = fish never starve)")
+	flag.BoolVar(&wator.NoFishBreed, "no-fish-breed", false, "fish never breed (study pure die-off)")
+	flag.BoolVar(&wator.NoSharkBreed, "no-shark-breed", false, "sharks never breed (study pure die-off)")
+	flag.Float64Var(&wator.FishStayProb, "fish-stay-prob", wator.FishStayProb, "probability that a fish stays put although it could move")
+	flag.Float64Var(&wator.SharkMoveProb, "shark-move-prob", wator.SharkMoveProb, "probability that a shark which did not eat moves")
+	advise := flag.Bool("advise", false, "print warnings about settings that likely give trivial dynamics, then run")
+	seed := flag.Int64("seed", 0, "seed of the random source (default: taken from the clock and printed)")
+	dryRun := flag.Bool("dry-run", false, "validate the configuration, print the run plan and exit")
+	flag.BoolVar(&async, "async", false, "simulate in a background goroutine, independent of the frame rate")
+	flag.Float64Var(&simTPS, "sim-tps", 0, "target simulation ticks per second, fractions for less than one (0 = unthrottled with -async, else 60)")
+	flag.Float64Var(&simTPS, "tps", 0, "short for -sim-tps")
+	ndjsonPath := flag.String("ndjson", "", "write per-tick metrics as newline-delimited JSON to this file")
+	flag.BoolVar(&wator.ClusterMetric, "clustering", false, "add a spatial clustering metric (-1 alternating, 0 random, 1 segregated) to -ndjson")
+	timersPath := flag.String("dump-timers", "", "write histograms of the breed and starve timers as CSV to this file at the end of the run")
+	flag.IntVar(&timersEvery, "dump-timers-every", 0, "dump-timers: also write the histograms every N ticks (0 = only at the end)")
+	httpAddr := flag.String("http", "", "serve the state and control endpoints on this address, e.g. :8080")
+	flag.BoolVar(&showTiles, "show-tiles", false, "draw the tile boundaries used by the parallel update")
+	flag.BoolVar(&fade, "fade", false, "crossfade cell colors when their state changes (toggle with F)")
+	flag.IntVar(&fadeFrames, "fade-frames", fadeFrames, "fade: rendered frames a color change takes")
+	flag.BoolVar(&tintTiles, "tint-tiles", false, "tint each tile by the worker that processes it")
+	historyLen := flag.Int("history", 0, "keep the last N states so Backspace can step back (costs N x ~2.7 MiB)")
+	flag.IntVar(&wator.ReseedEvery, "reseed-every", 0, "every N ticks add -reseed-fish/-reseed-sharks new creatures (0 = never)")
+	flag.IntVar(&wator.ReseedFish, "reseed-fish", 0, "fish added at each reseed")
+	flag.IntVar(&wator.ReseedSharks, "reseed-sharks", 0, "sharks added at each reseed")
+	benchOut := flag.String("bench-out", "", "bench mode: write CSV results to this file")
+	benchAppend := flag.Bool("bench-append", false, "bench mode: append to -bench-out instead of truncating it")
+	comparePath := flag.String("compare", "", "also run a second world whose parameters are overridden by this JSON file")
+	ensemble := flag.Int("ensemble", 0, "headless: simulate N seeds of the same settings for up to -ticks ticks and print aggregate CSV")
+	headless := flag.Bool("headless", false, "simulate without a window and exit after -ticks ticks")
+	ticks := flag.Int("ticks", 1000, "headless mode: number of ticks to simulate")
+	breakAt := flag.Int("break-at", -1, "simulate headless up to tick K, print its fingerprint and exit (-1 = off)")
+	dumpPath := flag.String("dump", "", "with -break-at: write the state at that tick to this `file` (.json, .png, .txt or else binary)")
+	dumpInitial := flag.String("dump-initial", "", "write the world to this `file` before the first tick (.json, .png, .txt or else binary), then run")
+	loadPath := flag.String("load", "", "resume the run saved in this state `file` (.json, .txt or else binary) instead of building a new world")
+	initImage := flag.String("init-image", "", "build the initial world from this PNG `file`, one cell per pixel colored like the fish, sharks and water")
+	flag.IntVar(&checkpointEvery, "checkpoint-every", 0, "save a checkpoint every N ticks (0 = off)")
+	flag.StringVar(&checkpointDir, "checkpoint-dir", checkpointDir, "directory the checkpoints are written to and resumed from")
+	flag.IntVar(&checkpointKeep, "checkpoint-keep", checkpointKeep, "number of newest checkpoints to keep")
+	resume := flag.Bool("resume", false, "resume from the newest checkpoint in -checkpoint-dir, or start a new run if there is none")
+	gifOut := flag.String("gif", "", "record the run as an animated GIF to this file")
+	flag.BoolVar(&gifPerTick, "gif-per-tick", false, "GIF: one frame per simulation tick instead of per rendered frame")
+	flag.IntVar(&gifMaxFrames, "gif-max-frames", gifMaxFrames, "GIF: stop recording after N frames")
+	flag.Float64Var(&gifSeconds, "gif-seconds", 0, "GIF: stop recording once the GIF lasts this many seconds (0 = no limit)")
+	flag.IntVar(&gifSkip, "gif-skip", gifSkip, "GIF: keep only every N-th frame")
+	flag.StringVar(&gifPalette, "gif-palette", "", "GIF: record in the colors of this theme instead of the display colors")
+	exportFrames := flag.String("export-frames", "", "write the grid as numbered PNGs to this `dir`, at the start and every -every ticks")
+	flag.IntVar(&framesEvery, "every", framesEvery, "export-frames: write a frame every N ticks")
+	validate := flag.Bool("validate", false, "run -ticks ticks both serially and in parallel from the same state and report the first tick where they differ")
+	replayOut := flag.String("replay-out", "", "record the seed, settings and interactive edits to a .wtr `file` for the replay mode")
+	recordPath := flag.String("record", "", "stream one frame per tick to ffmpeg, which writes this video `file` (e.g. out.mp4)")
+	flag.IntVar(&videoFPS, "record-fps", videoFPS, "record: frames per second of the video")
+	flag.IntVar(&videoScale, "record-scale", videoScale, "record: pixels per cell side in the video")
+	summaryPath := flag.String("summary-png", "", "at the end of the run write per-cell occupancy as a heat map PNG to this file")
+	zonesPath := flag.String("zones", "", "JSON `file` with rectangular zones overriding the breed/starve parameters")
+	flag.BoolVar(&wator.Stacking, "stacking", false, "let up to -max-stack fish share a cell as a school")
+	flag.IntVar(&wator.MaxStack, "max-stack", wator.MaxStack, "stacking mode: largest number of fish per cell")
+	topo := flag.String("topology", wator.TopoSquare, "grid topology: square (four neighbors) or hex (six neighbors, odd rows shifted)")
+	order := flag.String("tile-order", wator.OrderScan, "order of the cells within a tile: scan (row by row), random or checkerboard")
+	sched := flag.String("scheduler", wator.SchedStatic, "tile scheduling: static (one goroutine per tile), queue (worker pool pulling smaller tiles), phased (even, then odd stripes; repeatable with any thread count) or owned (tile borders merged serially; repeatable for a given thread count)")
+	flag.IntVar(&wator.TileSplit, "tile-split", wator.TileSplit, "queue scheduler: tiles per worker")
+	flag.BoolVar(&wator.TrackFingerprint, "fingerprint", false, "keep a rolling state fingerprint during each tick and add it to -ndjson")
+	flag.IntVar(&wator.FingerprintCheck, "fingerprint-check", 0, "with -fingerprint: verify it against a full scan every N ticks (0 = never)")
+	flag.BoolVar(&wator.TileTiming, "tile-timing", false, "measure each tile goroutine; log min/mean/max and add them to -ndjson")
+	renderMode := flag.String("render", "window", "output: window, ascii to draw in the terminal without a window, or tui for an interactive colored terminal view")
+	tui := flag.Bool("tui", false, "short for -render tui")
+	flag.IntVar(&asciiCols, "ascii-cols", 0, "ascii render: terminal columns (0 = $COLUMNS or 80)")
+	flag.IntVar(&asciiRows, "ascii-rows", 0, "ascii render: terminal rows for the grid (0 = $LINES-1 or 40)")
+	flag.IntVar(&asciiFPS, "ascii-fps", asciiFPS, "ascii render: redraws per second at most (0 = every tick)")
+	flag.StringVar(&asciiGlyphs, "ascii-glyphs", asciiGlyphs, "ascii and tui render: plain (one cell per character), half (1x2 cells) or braille (2x4 cells)")
+	flag.IntVar(&headlessTPS, "headless-tps", 0, "headless mode: at most N ticks per second (0 = as fast as possible)")
+	showVersion := flag.Bool("version", false, "print build and runtime information and exit")
+	flag.Parse()
+
+	if *showVersion || flag.Arg(0) == "version" {
+		printVersion(os.Stdout)
+		return
+	}
+
+	if *configPath != "" {
+		if err := loadConfigFile(*configPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	replayMode := flag.Arg(0) == "replay"
+	if replayMode {
+		if flag.NArg() != 2 {
+			fmt.Fprintln(os.Stderr, "usage: wa-tor [flags] replay file.wtr")
+			os.Exit(2)
+		}
+		if err := openReplay(flag.Arg(1)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	if err := applyTheme(themeName, bgHex, fishHex, sharkHex); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := wator.SetSharkHunt(*hunt); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := wator.SetOnExtinct(*extinctMode); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := wator.SetRNG(*rngName); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := wator.SetScheduler(*sched); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if wator.Sparse && *engine == wator.EngineDense {
+		*engine = wator.EngineSparse
+	}
+	if err := wator.SetEngine(*engine); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := wator.SetTileOrder(*order); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := wator.SetTopology(*topo); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := wator.SetSize(gridWidth, gridHeight); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := validateSettings(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *zonesPath != "" {
+		if err := loadZones(*zonesPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	if *historyLen > 0 && async {
+		fmt.Fprintln(os.Stderr, "-history cannot be combined with -async")
+		os.Exit(2)
+	}
+	if *comparePath != "" && (async || *historyLen > 0 || *ndjsonPath != "" || *timersPath != "" || *summaryPath != "" || *gifOut != "") {
+		fmt.Fprintln(os.Stderr, "-compare cannot be combined with -async, -history, -ndjson, -dump-timers, -summary-png or -gif")
+		os.Exit(2)
+	}
+	if fadeFrames < 0 {
+		fmt.Fprintln(os.Stderr, "fade-frames must be non-negative")
+		os.Exit(2)
+	}
+	if err := validateGIF(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if framesEvery < 1 {
+		fmt.Fprintln(os.Stderr, "every must be at least 1")
+		os.Exit(2)
+	}
+	if *exportFrames != "" && (*comparePath != "" || *ensemble > 0) {
+		fmt.Fprintln(os.Stderr, "-export-frames cannot be combined with -compare or -ensemble")
+		os.Exit(2)
+	}
+	if videoFPS < 1 || videoScale < 1 || videoScale > 16 {
+		fmt.Fprintln(os.Stderr, "record-fps must be at least 1 and record-scale between 1 and 16")
+		os.Exit(2)
+	}
+	if *recordPath != "" && (*comparePath != "" || *ensemble > 0) {
+		fmt.Fprintln(os.Stderr, "-record cannot be combined with -compare or -ensemble")
+		os.Exit(2)
+	}
+	if _, ok := glyphSizes[asciiGlyphs]; !ok {
+		fmt.Fprintf(os.Stderr, "unknown ascii glyphs %q (want plain, half or braille)\n", asciiGlyphs)
+		os.Exit(2)
+	}
+	if *tui && *renderMode == "window" {
+		*renderMode = "tui"
+	}
+	if *renderMode != "window" && *renderMode != "ascii" && *renderMode != "tui" {
+		fmt.Fprintf(os.Stderr, "unknown render mode %q (want window, ascii or tui)\n", *renderMode)
+		os.Exit(2)
+	}
+	if *renderMode != "window" && *comparePath != "" {
+		fmt.Fprintf(os.Stderr, "-render %s cannot be combined with -compare\n", *renderMode)
+		os.Exit(2)
+	}
+	if *ensemble < 0 {
+		fmt.Fprintln(os.Stderr, "ensemble must be non-negative")
+		os.Exit(2)
+	}
+	if *ensemble > 0 && (*comparePath != "" || async || *historyLen > 0 || *ndjsonPath != "" || *timersPath != "" || *summaryPath != "" || *gifOut != "" || *renderMode != "window") {
+		fmt.Fprintln(os.Stderr, "-ensemble cannot be combined with -compare, -async, -history, -ndjson, -dump-timers, -summary-png, -gif or -render ascii/tui")
+		os.Exit(2)
+	}
+	if timersEvery < 0 {
+		fmt.Fprintln(os.Stderr, "dump-timers-every must be non-negative")
+		os.Exit(2)
+	}
+	if timersEvery > 0 && *timersPath == "" {
+		fmt.Fprintln(os.Stderr, "-dump-timers-every needs -dump-timers")
+		os.Exit(2)
+	}
+	if *dumpPath != "" && *breakAt < 0 {
+		fmt.Fprintln(os.Stderr, "-dump needs -break-at")
+		os.Exit(2)
+	}
+	if *dumpInitial != "" && *ensemble > 0 {
+		fmt.Fprintln(os.Stderr, "-dump-initial cannot be combined with -ensemble")
+		os.Exit(2)
+	}
+	if *loadPath != "" && (*comparePath != "" || *ensemble > 0) {
+		fmt.Fprintln(os.Stderr, "-load cannot be combined with -compare or -ensemble")
+		os.Exit(2)
+	}
+	if checkpointEvery < 0 || checkpointKeep < 1 {
+		fmt.Fprintln(os.Stderr, "checkpoint-every must be non-negative and checkpoint-keep at least 1")
+		os.Exit(2)
+	}
+	if (checkpointEvery > 0 || *resume) && (*comparePath != "" || *ensemble > 0) {
+		fmt.Fprintln(os.Stderr, "-checkpoint-every and -resume cannot be combined with -compare or -ensemble")
+		os.Exit(2)
+	}
+	if *resume && *loadPath != "" {
+		fmt.Fprintln(os.Stderr, "-resume cannot be combined with -load")
+		os.Exit(2)
+	}
+	if *initImage != "" && (*loadPath != "" || *comparePath != "" || *ensemble > 0) {
+		fmt.Fprintln(os.Stderr, "-init-image cannot be combined with -load, -compare or -ensemble")
+		os.Exit(2)
+	}
+	if *validate && (*comparePath != "" || *ensemble > 0 || *breakAt >= 0) {
+		fmt.Fprintln(os.Stderr, "-validate cannot be combined with -compare, -ensemble or -break-at")
+		os.Exit(2)
+	}
+	if *replayOut != "" && (*comparePath != "" || *ensemble > 0) {
+		fmt.Fprintln(os.Stderr, "-replay-out cannot be combined with -compare or -ensemble")
+		os.Exit(2)
+	}
+	if replayMode && (*loadPath != "" || *resume || *initImage != "" || *zonesPath != "" || *comparePath != "" || *ensemble > 0 || *replayOut != "") {
+		fmt.Fprintln(os.Stderr, "replay cannot be combined with -load, -resume, -init-image, -zones, -compare, -ensemble or -replay-out")
+		os.Exit(2)
+	}
+	if replayMode && replayEnd >= 0 {
+		explicitTicks := false
+		flag.Visit(func(f *flag.Flag) { explicitTicks = explicitTicks || f.Name == "ticks" })
+		if !explicitTicks {
+			*ticks = replayEnd
+		}
+	}
+	if *breakAt >= 0 && (*comparePath != "" || *ensemble > 0 || *renderMode != "window") {
+		fmt.Fprintln(os.Stderr, "-break-at cannot be combined with -compare, -ensemble or -render ascii/tui")
+		os.Exit(2)
+	}
+	if wator.FingerprintCheck < 0 {
+		fmt.Fprintln(os.Stderr, "fingerprint-check must be non-negative")
+		os.Exit(2)
+	}
+	if wator.FingerprintCheck > 0 {
+		wator.TrackFingerprint = true
+	}
+	if *ticks < 0 {
+		fmt.Fprintln(os.Stderr, "ticks must be non-negative")
+		os.Exit(2)
+	}
+	if err := setHistory(*historyLen); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *advise {
+		printAdvice(os.Stderr)
+	}
+
+	runSeed = time.Now().UnixNano()
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			runSeed = *seed
+			benchSeed = *seed
+		}
+	})
+	wator.Seed(runSeed)
+
+	if *dryRun {
+		wator.InitWorld()
+		printPlan(os.Stdout)
+		return
+	}
+
+	// Simple arg check: if first arg is "bench", run benchmark mode
+	if flag.Arg(0) == "bench" {
+		if err := runBenchmarks(*benchOut, *benchAppend); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// ==== normal graphical mode ====
+	runtime.GOMAXPROCS(wator.Threads)
+
+	if *ensemble > 0 {
+		if err := runEnsemble(os.Stdout, os.Stderr, *ensemble, *ticks, runSeed); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *resume {
+		path, err := latestCheckpoint(checkpointDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if path == "" {
+			fmt.Fprintf(os.Stderr, "no checkpoint in %s, starting a new run\n", checkpointDir)
+		}
+		*loadPath = path
+	}
+
+	var err error
+	if *comparePath != "" {
+		if err := setupCompare(*comparePath, runSeed); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	} else if replayMode {
+		if err := startReplay(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", flag.Arg(1), err)
+			os.Exit(2)
+		}
+		runSeed = wator.CurrentSeed()
+		fmt.Fprintf(os.Stderr, "replaying %s from tick %d, seed %d\n", flag.Arg(1), wator.Tick, runSeed)
+	} else if *loadPath != "" {
+		if err := loadState(*loadPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		runSeed = wator.CurrentSeed()
+		fmt.Fprintf(os.Stderr, "resumed %s at tick %d, seed %d\n", *loadPath, wator.Tick, runSeed)
+		if *breakAt >= 0 && *breakAt < wator.Tick {
+			fmt.Fprintf(os.Stderr, "-break-at %d is before the loaded tick %d\n", *breakAt, wator.Tick)
+			os.Exit(2)
+		}
+	} else if *initImage != "" {
+		if err := loadInitImage(*initImage); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "seed %d, %d fish and %d sharks from %s\n",
+			runSeed, wator.CountFish(), wator.CountSharks(), *initImage)
+	} else {
+		fmt.Fprintf(os.Stderr, "seed %d\n", runSeed)
+		wator.InitWorld()
+	}
+	if *dumpInitial != "" {
+		if err := writeDump(*dumpInitial); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *validate {
+		if err := runValidate(os.Stdout, *ticks); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *replayOut != "" {
+		if err := startReplayOut(*replayOut); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *ndjsonPath != "" {
+		if err := openNDJSON(*ndjsonPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *timersPath != "" {
+		if err := openTimers(*timersPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *summaryPath != "" {
+		wator.EnableSummary()
+	}
+	if *renderMode != "window" || *breakAt >= 0 || !haveWindow {
+		*headless = true
+	}
+	if *headless {
+		// there are no rendered frames to capture
+		gifPerTick = true
+	}
+	if *gifOut != "" {
+		startGIF(*gifOut)
+	}
+	if *exportFrames != "" {
+		if err := startFrames(*exportFrames); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *recordPath != "" {
+		if err := startVideo(*recordPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *headless {
+		if *breakAt >= 0 {
+			err = runBreak(os.Stdout, *breakAt, *dumpPath)
+		} else if compareMode {
+			err = runCompareHeadless(os.Stdout, *ticks)
+		} else if *renderMode == "ascii" {
+			err = runASCII(os.Stdout, *ticks)
+		} else if *renderMode == "tui" {
+			err = runTUI(os.Stdout, *ticks)
+		} else {
+			err = runHeadless(os.Stdout, *ticks)
+		}
+		closeOutputs(*summaryPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	fmt.Printf("Initial fish: %d\n", wator.CountFish())
+	if *httpAddr != "" {
+		startHTTP(*httpAddr)
+	}
+	if async {
+		startSim()
+	}
+	err = runWindow()
+	stopSim()
+	closeOutputs(*summaryPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,56 @@
+package wator
+
+/// @file claim.go
+/// @brief Per-cell claims that keep the tile goroutines of `Update()` apart.
+/// @details `claims` holds one word per cell, indexed y*Width+x like the
+/// worklists. A worker claims a cell by swapping its word from 0 to 1 with
+/// an atomic compare-and-swap and releases it by storing 0, so creatures
+/// only wait for each other when they touch the very same cells, not
+/// whenever they share a tile; a creature two or more cells inside its
+/// tile cannot meet another tile's creatures and claims nothing. The words
+/// are all 0 again when `Update()` returns, so the buffer is kept across
+/// ticks and only reallocated when the grid size changes. Cells are always
+/// claimed in ascending index order, so two workers can never wait for
+/// each other in a cycle. A worker that finds a cell taken yields with
+/// `runtime.Gosched()` instead of spinning, since the holder may be
+/// waiting for the same CPU.
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+var claims []uint32 // 1 while a worker holds the cell, 0 otherwise
+
+// / @brief Size `claims` for the current grid.
+func prepareClaims() {
+	if len(claims) != Width*Height {
+		claims = make([]uint32, Width*Height)
+	}
+}
+
+// / @brief Wait until cell `c` is free and claim it.
+func claimCell(c int) {
+	for !atomic.CompareAndSwapUint32(&claims[c], 0, 1) {
+		runtime.Gosched()
+	}
+}
+
+// / @brief Release a cell taken with `claimCell()`.
+func releaseCell(c int) {
+	atomic.StoreUint32(&claims[c], 0)
+}
+
+// / @brief Claim cells in the given order, which must be ascending.
+func claimCells(cells []int) {
+	for _, c := range cells {
+		claimCell(c)
+	}
+}
+
+// / @brief Release cells taken with `claimCells()`.
+func releaseCells(cells []int) {
+	for i := len(cells) - 1; i >= 0; i-- {
+		releaseCell(cells[i])
+	}
+}
@@ -0,0 +1,60 @@
+package wator
+
+/// @file cluster.go
+/// @brief How clumped the cell states are, as one number per tick.
+/// @details The metric compares every pair of side-by-side cells (four
+/// neighbors, or six with `-topology hex`, wrapping at the edges, each
+/// pair counted once) and measures
+/// how much more often the two cells are in the same state (empty, fish or
+/// shark) than they would be if the same creatures were scattered at
+/// random, in the manner of Cohen's kappa:
+///
+///	clustering = (observed - expected) / (1 - expected)
+///
+/// `observed` is the share of pairs with equal states and `expected` the
+/// sum of the squared shares of the three states. A world of separated
+/// patches of one state each scores close to 1, random placement about 0,
+/// and a checkerboard of two states -1. A world with only one state has
+/// no pattern and scores 0. With `-clustering` the value is added to the
+/// `-ndjson` records as `"clustering"`.
+
+// / @brief Add the clustering metric to the `-ndjson` records.
+var ClusterMetric bool = false
+
+// / @brief Clustering of `cells`, between -1 (alternating) and 1 (segregated).
+// / @param cells The grid to measure; not modified.
+// / @return float64 0 for random placement or a uniform grid.
+func clustering(cells []uint8) float64 {
+	var counts [3]int
+	same, pairs := 0, 0
+	for y := 0; y < Height; y++ {
+		// the neighbors to the east and below; the others count the
+		// remaining pairs
+		var forward [][2]int
+		for _, d := range neighborOffsets(y) {
+			if d[1] > 0 || (d[1] == 0 && d[0] > 0) {
+				forward = append(forward, d)
+			}
+		}
+		for x := 0; x < Width; x++ {
+			s := cells[Index(x, y)]
+			counts[s]++
+			for _, d := range forward {
+				if cells[Index((x+d[0]+Width)%Width, (y+d[1])%Height)] == s {
+					same++
+				}
+			}
+			pairs += len(forward)
+		}
+	}
+	n := float64(Width * Height)
+	expected := 0.0
+	for _, c := range counts {
+		expected += float64(c) / n * float64(c) / n
+	}
+	if expected >= 1 {
+		return 0
+	}
+	observed := float64(same) / float64(pairs)
+	return (observed - expected) / (1 - expected)
+}
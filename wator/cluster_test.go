@@ -0,0 +1,53 @@
+package wator
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// / @brief A text grid of `w` x `h` cells whose glyph at (x, y) is `at(x, y)`.
+func textGrid(w, h int, at func(x, y int) byte) string {
+	var b strings.Builder
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			b.WriteByte(at(x, y))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// / @brief The clustering metric at its extremes: 0 for one state, -1 for a
+// / checkerboard, close to 1 for wide bands and about 0 for random placement.
+func TestClusteringExtremes(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		cells    string
+		min, max float64
+	}{
+		{"empty", textGrid(12, 8, func(x, y int) byte { return '.' }), 0, 0},
+		{"all fish", textGrid(12, 8, func(x, y int) byte { return 'f' }), 0, 0},
+		{"checkerboard", textGrid(12, 8, func(x, y int) byte { return "fS"[(x+y)%2] }), -1, -1},
+		{"bands", textGrid(60, 40, func(x, y int) byte { return ".fS"[x/20] }), 0.95, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			newTestWorld(t, tc.cells, nil)
+			if c := clustering(Grid); c < tc.min-1e-9 || c > tc.max+1e-9 {
+				t.Errorf("clustering %v, want in [%v, %v]", c, tc.min, tc.max)
+			}
+		})
+	}
+
+	t.Run("random", func(t *testing.T) {
+		w, err := NewWorld(WithSize(100, 80), WithFish(2400), WithSharks(800), WithThreads(1), WithSeed(4))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Load()
+		defer w.Unload()
+		if c := clustering(Grid); math.Abs(c) > 0.03 {
+			t.Errorf("clustering of a fresh random world %v, want about 0", c)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package wator
+
+import (
+	"fmt"
+	"testing"
+)
+
+// / @brief Events counted during one tick.
+type tickEvents struct {
+	fishBirths, fishDeaths   int
+	sharkBirths, sharkDeaths int
+	meals                    int
+}
+
+// / @brief Simulate `ticks` ticks of the loaded world and fail at the first
+// / one that does not conserve creatures.
+// / @details After every tick
+// /
+// /	fish   = previous fish   + fish births  - fish deaths (eaten or starved)
+// /	sharks = previous sharks + shark births - shark deaths
+// /
+// / must hold, counted with the events of events.go, and no meal may go
+// / without a dead fish. These are the classic bugs of a parallel update: a
+// / creature written both at its old and its new cell, or vanishing without
+// / being eaten or starving. Reseeding must be off, since it adds creatures
+// / without events.
+func checkConservation(t testing.TB, ticks int) {
+	t.Helper()
+	var ev tickEvents
+	OnBirth = func(x, y int, species uint8) {
+		if species == 1 {
+			ev.fishBirths++
+		} else {
+			ev.sharkBirths++
+		}
+	}
+	OnDeath = func(x, y int, species uint8) {
+		if species == 1 {
+			ev.fishDeaths++
+		} else {
+			ev.sharkDeaths++
+		}
+	}
+	OnEat = func(x, y int, species uint8) { ev.meals++ }
+	defer func() { OnBirth, OnDeath, OnEat = nil, nil, nil }()
+
+	fish, sharks := CountFish(), CountSharks()
+	for i := 0; i < ticks; i++ {
+		ev = tickEvents{}
+		if err := Update(); err != nil {
+			t.Fatal(err)
+		}
+		newFish, newSharks := CountFish(), CountSharks()
+		wantFish := fish + ev.fishBirths - ev.fishDeaths
+		wantSharks := sharks + ev.sharkBirths - ev.sharkDeaths
+		if newFish != wantFish || newSharks != wantSharks || ev.fishDeaths < ev.meals {
+			t.Fatalf("tick %d: fish %d -> %d (births %d, deaths %d, want %d), "+
+				"sharks %d -> %d (births %d, deaths %d, want %d), %d meals",
+				Tick, fish, newFish, ev.fishBirths, ev.fishDeaths, wantFish,
+				sharks, newSharks, ev.sharkBirths, ev.sharkDeaths, wantSharks, ev.meals)
+		}
+		fish, sharks = newFish, newSharks
+	}
+}
+
+// / @brief Every tick conserves creatures, for several seeds, thread counts
+// / and settings that change how creatures move and breed.
+func TestConservation(t *testing.T) {
+	const side, ticks = 64, 150
+	for _, variant := range []struct {
+		name string
+		cfg  func()
+	}{
+		{"default", nil},
+		{"stacking", func() { Stacking = true }},
+		{"fish starve", func() { FishStarve = 4 }},
+		{"hex", func() { SetTopology(TopoHex) }},
+		{"greedy vision", func() { SharkHunt, SharkVision = HuntGreedy, 3 }},
+		{"phased", func() { SetScheduler(SchedPhased) }},
+		{"owned", func() { SetScheduler(SchedOwned) }},
+		{"sparse", func() { SetEngine(EngineSparse) }},
+	} {
+		for _, threads := range []int{1, 3, 8} {
+			for _, seed := range []int64{1, 2} {
+				t.Run(fmt.Sprintf("%s/threads=%d/seed=%d", variant.name, threads, seed), func(t *testing.T) {
+					w, err := NewWorld(WithSize(side, side), WithFish(side*side/16), WithSharks(side*side/40),
+						WithThreads(threads), WithSeed(seed))
+					if err != nil {
+						t.Fatal(err)
+					}
+					w.Load()
+					defer w.Unload()
+					if variant.cfg != nil {
+						variant.cfg()
+						if err := ValidateConfig(); err != nil {
+							t.Fatal(err)
+						}
+						InitWorld()
+					}
+					checkConservation(t, ticks)
+				})
+			}
+		}
+	}
+}
+
+// / @brief Fish that breed every tick and their sharks, packed along the
+// / tile borders, are conserved while neighboring tiles all write into each
+// / other. Meant for `go test -race`, which also reports any write to a
+// / shared cell that is not under a claim.
+func TestBreedingAtTileBorders(t *testing.T) {
+	const side = 32
+	for _, sched := range []string{SchedStatic, SchedQueue} {
+		t.Run(sched, func(t *testing.T) {
+			w, err := NewWorld(WithSize(side, side), WithFish(0), WithSharks(0), WithThreads(4),
+				WithFishBreed(1), WithSharkBreed(6), WithSharkStarve(3), WithSeed(1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Load()
+			defer w.Unload()
+			SetScheduler(sched)
+			cols, rows, tileW, tileH := TileLayout()
+			if cols*rows < 4 {
+				t.Fatalf("layout %dx%d has fewer than 4 tiles", cols, rows)
+			}
+
+			// two cells either side of every tile border, the torus seam included
+			border := func(v, tile int) bool { d := v % tile; return d < 2 || d >= tile-2 }
+			cells := make([][]uint8, side)
+			for y := range cells {
+				cells[y] = make([]uint8, side)
+				for x := range cells[y] {
+					if border(x, tileW) || border(y, tileH) {
+						cells[y][x] = uint8(1 + (x+y)%20/19) // one shark in twenty
+					}
+				}
+			}
+			if err := BuildWorld(cells); err != nil {
+				t.Fatal(err)
+			}
+			checkConservation(t, 100)
+			if CountFish() == 0 {
+				t.Error("the fish died out, so breeding was not exercised to the end")
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package wator
+
+/// @file distance.go
+/// @brief Distances on the torus for spatial analysis.
+/// @details The grid wraps in both directions, so the distance between two
+/// cells uses the shorter way around on each axis. These helpers are for
+/// analyzers between ticks and are not used by `Update()`; the same calling
+/// rules as for iter.go apply.
+
+import "math"
+
+// / @brief Shorter of the two ways between `a` and `b` on a ring of size `n`.
+func torusDelta(a, b, n int) int {
+	d := ((a-b)%n + n) % n
+	if d > n-d {
+		d = n - d
+	}
+	return d
+}
+
+// / @brief Euclidean distance between two cells, wrapping around the edges.
+func TorusDistance(x1, y1, x2, y2 int) float64 {
+	dx := torusDelta(x1, x2, Width)
+	dy := torusDelta(y1, y2, Height)
+	return math.Hypot(float64(dx), float64(dy))
+}
+
+// / @brief Find the creature of kind `state` closest to (x, y).
+// / @details Searches square rings of growing radius around the cell and
+// / stops once no closer cell can remain. The cell (x, y) itself is not
+// / considered, so this gives the nearest neighbor of a creature.
+// / @param state 1 for fish, 2 for sharks.
+// / @return nx, ny The nearest such creature.
+// / @return dist Its `TorusDistance` from (x, y).
+// / @return ok False if there is no such creature besides (x, y).
+func NearestCreature(x, y int, state uint8) (nx, ny int, dist float64, ok bool) {
+	dist = math.Inf(1)
+	check := func(dx, dy int) {
+		cx, cy := wrapCoords(x+dx, y+dy)
+		if Grid[Index(cx, cy)] != state || (cx == x && cy == y) {
+			return
+		}
+		if d := math.Hypot(float64(dx), float64(dy)); d < dist {
+			nx, ny, dist, ok = cx, cy, d, true
+		}
+	}
+
+	maxR := Width / 2
+	if Height/2 > maxR {
+		maxR = Height / 2
+	}
+	for r := 1; r <= maxR && float64(r) < dist; r++ {
+		for d := -r; d <= r; d++ {
+			check(d, -r)
+			check(d, r)
+		}
+		for d := -r + 1; d < r; d++ {
+			check(-r, d)
+			check(r, d)
+		}
+	}
+	if ok {
+		dist = TorusDistance(x, y, nx, ny)
+	}
+	return nx, ny, dist, ok
+}
@@ -0,0 +1,82 @@
+package wator
+
+import (
+	"math"
+	"testing"
+)
+
+// / @brief Distances take the shorter way round, across edges and corners,
+// / on grids of even and odd size.
+func TestTorusDistance(t *testing.T) {
+	for _, tc := range []struct {
+		w, h           int
+		x1, y1, x2, y2 int
+		want           float64
+	}{
+		{10, 6, 3, 2, 3, 2, 0},
+		{10, 6, 0, 0, 9, 0, 1},                // across the left edge
+		{10, 6, 4, 0, 4, 5, 1},                // across the top edge
+		{10, 6, 0, 0, 9, 5, math.Sqrt2},       // corner to corner
+		{10, 6, 9, 0, 0, 5, math.Sqrt2},       // the other two corners
+		{10, 6, 0, 0, 5, 3, math.Hypot(5, 3)}, // half way round both axes
+		{10, 6, 1, 1, 8, 4, math.Hypot(3, 3)},
+		{7, 5, 0, 0, 4, 0, 3}, // odd width: 3 one way, 4 the other
+		{7, 5, 0, 0, 3, 0, 3},
+		{7, 5, 6, 4, 0, 0, math.Sqrt2},
+		{1, 1, 0, 0, 0, 0, 0},
+	} {
+		w, err := NewWorld(WithSize(tc.w, tc.h), WithFish(0), WithSharks(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Do(func() {
+			for _, p := range [][4]int{{tc.x1, tc.y1, tc.x2, tc.y2}, {tc.x2, tc.y2, tc.x1, tc.y1}} {
+				if got := TorusDistance(p[0], p[1], p[2], p[3]); math.Abs(got-tc.want) > 1e-12 {
+					t.Errorf("%dx%d: (%d,%d)-(%d,%d) = %g, want %g", tc.w, tc.h, p[0], p[1], p[2], p[3], got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+// / @brief The nearest creature is found across edges and corners, and is
+// / the one a scan of all creatures finds.
+func TestNearestCreature(t *testing.T) {
+	newTestWorld(t, `
+f.......f
+.........
+....f....
+.........
+f.......S`, nil)
+	if x, y, d, ok := NearestCreature(0, 0, 1); !ok || d != 1 || y != 4 || x != 0 {
+		t.Errorf("nearest fish to the corner: (%d,%d) at %g, ok=%v, want (0,4) at 1 over the top edge", x, y, d, ok)
+	}
+	if x, y, d, ok := NearestCreature(0, 0, 2); !ok || d != math.Sqrt2 || x != 8 || y != 4 {
+		t.Errorf("nearest shark to the corner: (%d,%d) at %g, ok=%v, want the opposite corner at sqrt 2", x, y, d, ok)
+	}
+	if _, _, _, ok := NearestCreature(8, 4, 2); ok {
+		t.Error("the only shark found another one")
+	}
+
+	for _, size := range [][2]int{{24, 16}, {17, 9}} {
+		w, err := NewWorld(WithSize(size[0], size[1]), WithFish(12), WithSharks(5), WithThreads(1), WithSeed(4))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Do(func() {
+			ForEachCreature(func(x, y int, state uint8, _, _ int) {
+				want, found := math.Inf(1), false
+				ForEachCreature(func(ox, oy int, other uint8, _, _ int) {
+					if other == state && (ox != x || oy != y) {
+						want, found = min(want, TorusDistance(x, y, ox, oy)), true
+					}
+				})
+				_, _, got, ok := NearestCreature(x, y, state)
+				if ok != found || (found && math.Abs(got-want) > 1e-12) {
+					t.Errorf("%dx%d: nearest to (%d,%d) at %g (ok=%v), scan gives %g (found=%v)",
+						size[0], size[1], x, y, got, ok, want, found)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package wator
+
+/// @file events.go
+/// @brief Callbacks fired on births, deaths and meals during `Update()`.
+/// @details Set any of `OnBirth`, `OnDeath` and `OnEat` to observe what
+/// happens in a tick, e.g. for custom analyzers:
+///
+///	OnBirth   a creature was born at (x, y); a school growing by one counts
+///	OnDeath   a creature at (x, y) starved or was eaten (once per fish of
+///	          an eaten or starved school)
+///	OnEat     a shark ate at (x, y); the fish also gets an OnDeath
+///
+/// Workers record the events in per-tile buffers while the tick runs, and
+/// they are dispatched on the calling goroutine once all tiles have
+/// finished, tile by tile, so callbacks never run concurrently and need no
+/// locking. They run before `Update()` returns, with `Grid` already showing
+/// the new state and `Tick` counting the finished tick; they must not call
+/// `Update()`. Creatures added outside `Update()` (initial placement,
+/// reseeding, the builder) fire no events. With all three callbacks nil no
+/// events are recorded.
+
+// / @brief Event callbacks; `species` is 1 for a fish and 2 for a shark.
+var OnBirth func(x, y int, species uint8)
+var OnDeath func(x, y int, species uint8)
+var OnEat func(x, y int, species uint8)
+
+const (
+	eventBirth = iota
+	eventDeath
+	eventEat
+)
+
+// / @brief One recorded event.
+type cellEvent struct {
+	kind    uint8
+	species uint8
+	x, y    int32
+}
+
+// / @brief Per-tile event buffers of the current tick.
+var tileEvents [][]cellEvent
+
+// / @brief Reports whether any callback is set.
+func eventsEnabled() bool {
+	return OnBirth != nil || OnDeath != nil || OnEat != nil
+}
+
+// / @brief Empty the buffers for a tick with `n` tiles.
+func resetEvents(n int) {
+	for len(tileEvents) < n {
+		tileEvents = append(tileEvents, nil)
+	}
+	for i := range tileEvents {
+		tileEvents[i] = tileEvents[i][:0]
+	}
+}
+
+// / @brief Invoke the callbacks for all recorded events, tile by tile.
+func dispatchEvents() {
+	for _, list := range tileEvents {
+		for _, e := range list {
+			var fn func(x, y int, species uint8)
+			switch e.kind {
+			case eventBirth:
+				fn = OnBirth
+			case eventDeath:
+				fn = OnDeath
+			case eventEat:
+				fn = OnEat
+			}
+			if fn != nil {
+				fn(int(e.x), int(e.y), e.species)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package wator
+
+import (
+	"fmt"
+	"testing"
+)
+
+// / @brief Births minus deaths each tick change the number of occupied cells
+// / the tiles count while filling the buffer; births land on a creature of
+// / their species (fish may be eaten later in their tick) and meals on a
+// / shark. Without callbacks no events are recorded.
+func TestEventsMatchCounters(t *testing.T) {
+	for _, threads := range []int{1, 4} {
+		t.Run(fmt.Sprintf("threads=%d", threads), func(t *testing.T) {
+			w, err := NewWorld(WithSize(48, 32), WithFish(150), WithSharks(40), WithThreads(threads), WithSeed(6))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Load()
+			defer w.Unload()
+
+			var births, deaths, meals int
+			OnBirth = func(x, y int, species uint8) {
+				births++
+				if s := Grid[Index(x, y)]; s != species && !(species == 1 && s == 2) {
+					t.Errorf("tick %d: birth of species %d at (%d,%d), which holds %d", Tick, species, x, y, s)
+				}
+			}
+			OnDeath = func(x, y int, species uint8) { deaths++ }
+			OnEat = func(x, y int, species uint8) {
+				meals++
+				if s := Grid[Index(x, y)]; s != 2 {
+					t.Errorf("tick %d: meal at (%d,%d), which holds %d", Tick, x, y, s)
+				}
+			}
+			defer func() { OnBirth, OnDeath, OnEat = nil, nil, nil }()
+
+			prev := CountFish() + CountSharks()
+			for i := 0; i < 80; i++ {
+				births, deaths, meals = 0, 0, 0
+				if err := Update(); err != nil {
+					t.Fatal(err)
+				}
+				if want := prev + births - deaths; occupied != want {
+					t.Fatalf("tick %d: %d cells occupied, %d before + %d births - %d deaths = %d",
+						Tick, occupied, prev, births, deaths, want)
+				}
+				if meals > deaths {
+					t.Fatalf("tick %d: %d meals but only %d deaths", Tick, meals, deaths)
+				}
+				prev = occupied
+			}
+
+			// the buffers are only emptied while events are on
+			OnBirth, OnDeath, OnEat = nil, nil, nil
+			resetEvents(len(tileEvents))
+			for i := 0; i < 5; i++ {
+				if err := Update(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for id, list := range tileEvents {
+				if len(list) != 0 {
+					t.Errorf("tile %d recorded %d events without callbacks", id, len(list))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package wator
+
+/// @file extinct.go
+/// @brief What to do once every creature has died.
+/// @details An empty grid can never come back to life, so instead of
+/// running `Update()` on it forever the behavior is chosen with
+/// `-on-extinct`: `stop` halts the simulation and shows "extinct" in the
+/// window, `reset` builds a fresh world with `InitWorld()` and carries on.
+
+import "fmt"
+
+const (
+	ExtinctStop  = "stop"
+	ExtinctReset = "reset"
+)
+
+// / @brief Behavior on extinction, set with `-on-extinct`.
+var onExtinct string = ExtinctStop
+
+// / @brief Set once the simulation has halted on an empty grid.
+var Extinct bool = false
+
+// / @brief Validate and select the extinction behavior.
+// / @param mode Either "stop" or "reset".
+// / @return error Non-nil for an unknown mode.
+func SetOnExtinct(mode string) error {
+	switch mode {
+	case ExtinctStop, ExtinctReset:
+		onExtinct = mode
+		return nil
+	}
+	return fmt.Errorf("unknown extinction behavior %q (want %s or %s)", mode, ExtinctStop, ExtinctReset)
+}
+
+// / @brief Reports whether no fish or shark is left on the grid.
+// / @details Stops at the first creature, so it is cheap while anything lives.
+func worldEmpty() bool {
+	for _, s := range Grid {
+		if s != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// / @brief Apply the extinction behavior after a tick.
+// / @return bool True if the simulation should halt.
+func CheckExtinction() bool {
+	if !worldEmpty() {
+		return false
+	}
+	if onExtinct == ExtinctReset {
+		InitWorld()
+		return false
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package wator
+
+/// @file fingerprint.go
+/// @brief Hash of the complete world state for comparing runs.
+/// @details Two worlds have the same fingerprint exactly when (barring hash
+/// collisions) their cells and breed/starve timers match, which makes it a
+/// cheap way to check that two runs are identical.
+///
+/// The fingerprint is the XOR of one 64-bit hash per cell, mixing the
+/// cell's position, state and timers; a cell that is empty with both timers
+/// at 0 contributes 0. Because XOR needs no order and undoes itself, the
+/// value can also be kept up to date while `Update()` writes the next
+/// state: with `-fingerprint` every write to a next-state cell XORs the
+/// cell's old contribution out and its new one in, into a per-tile sum, and
+/// the sums are combined after the tiles finish. That costs a few
+/// operations per moved creature instead of a pass over the whole grid, so
+/// runs can be compared every tick (see the `fingerprint` field of
+/// `-ndjson`). `-fingerprint-check N` recomputes the full value every N
+/// ticks and fails the tick if the two disagree.
+
+import "fmt"
+
+// / @brief Keep the rolling fingerprint up to date in `Update()`.
+var TrackFingerprint bool = false
+
+// / @brief Compare the rolling and full fingerprints every N ticks (0 = never).
+var FingerprintCheck int = 0
+
+// / @brief Contribution of each next-state cell to the rolling fingerprint.
+var cellFP []uint64
+
+// / @brief Per-tile XOR sums of this tick's changes, indexed by tile id.
+var tileFP []uint64
+
+var rollingFP uint64
+var rollingValid bool = false
+
+// / @brief splitmix64 finalizer.
+func mix64(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// / @brief Contribution of one cell to the fingerprint.
+func cellHash(c int, state uint8, breed, starve int) uint64 {
+	if state == 0 && breed == 0 && starve == 0 {
+		return 0
+	}
+	h := mix64(uint64(c)<<2 | uint64(state))
+	h = mix64(h ^ uint64(int64(breed))*0x9e3779b97f4a7c15)
+	return mix64(h ^ uint64(int64(starve))*0xc2b2ae3d27d4eb4f)
+}
+
+// / @brief Fingerprint of `Grid`, `BreedTimer` and `StarveTimer` by a full scan.
+// / @return uint64 The fingerprint of the current state.
+func fingerprint() uint64 {
+	var fp uint64
+	for i, s := range Grid {
+		fp ^= cellHash(i, s, BreedTimer[i], StarveTimer[i])
+	}
+	return fp
+}
+
+// / @brief Fingerprint of the current state, rolling if possible.
+// / @details Returns the value `Update()` kept up to date when it is known
+// / to match the current state, and falls back to a full scan otherwise,
+// / e.g. after a reset or reseed.
+func TickFingerprint() uint64 {
+	if rollingValid {
+		return rollingFP
+	}
+	return fingerprint()
+}
+
+// / @brief Prepare the per-tile sums before a tick.
+// / @details `cellFP` is cleared together with the next-state buffers (see
+// / `clearBuffers()`), since the next state starts out empty.
+func resetRollingFP(tiles int) {
+	if stalePlane(cellFP) {
+		cellFP = NewPlane[uint64]()
+	}
+	if cap(tileFP) < tiles {
+		tileFP = make([]uint64, tiles)
+	}
+	tileFP = tileFP[:tiles]
+	for i := range tileFP {
+		tileFP[i] = 0
+	}
+}
+
+// / @brief Combine the per-tile sums into the fingerprint of the new state.
+// / @return error Non-nil if a check is due and the full scan disagrees.
+func finishRollingFP() error {
+	rollingFP = 0
+	for _, h := range tileFP {
+		rollingFP ^= h
+	}
+	rollingValid = true
+	if FingerprintCheck > 0 && Tick%FingerprintCheck == 0 {
+		if full := fingerprint(); full != rollingFP {
+			return fmt.Errorf("tick %d: rolling fingerprint %016x, full scan %016x", Tick, rollingFP, full)
+		}
+	}
+	return nil
+}
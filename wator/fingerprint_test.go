@@ -0,0 +1,83 @@
+package wator
+
+import (
+	"fmt"
+	"testing"
+)
+
+// / @brief Two deterministic runs from the same seed have the same
+// / fingerprint after every tick, single-threaded and with the phased
+// / scheduler on four threads.
+func TestDeterministicFingerprints(t *testing.T) {
+	for _, threads := range []int{1, 4} {
+		run := func() []uint64 {
+			w, err := NewWorld(WithSize(60, 40), WithFish(300), WithSharks(80), WithThreads(threads), WithSeed(11))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fps []uint64
+			w.Do(func() {
+				Deterministic = true
+				if threads > 1 {
+					SetScheduler(SchedPhased)
+				}
+				InitWorld()
+			})
+			for i := 0; i < 30; i++ {
+				step(t, w, 1)
+				w.Do(func() { fps = append(fps, fingerprint()) })
+			}
+			return fps
+		}
+		a, b := run(), run()
+		for i := range a {
+			if a[i] != b[i] {
+				t.Fatalf("%d threads: tick %d: fingerprints %016x and %016x", threads, i+1, a[i], b[i])
+			}
+		}
+	}
+}
+
+// / @brief The fingerprint kept up to date during the tick equals a full
+// / scan after every tick, for the settings that write cells differently.
+func TestRollingFingerprint(t *testing.T) {
+	for _, variant := range []struct {
+		name string
+		cfg  func()
+	}{
+		{"default", nil},
+		{"stacking", func() { Stacking = true }},
+		{"fish starve", func() { FishStarve = 4 }},
+		{"hex", func() { SetTopology(TopoHex) }},
+		{"owned", func() { SetScheduler(SchedOwned) }},
+		{"sparse", func() { SetEngine(EngineSparse) }},
+		{"reseed", func() { ReseedEvery, ReseedFish, ReseedSharks = 7, 20, 5 }},
+	} {
+		for _, threads := range []int{1, 4} {
+			t.Run(fmt.Sprintf("%s/threads=%d", variant.name, threads), func(t *testing.T) {
+				w, err := NewWorld(WithSize(60, 40), WithFish(300), WithSharks(80), WithThreads(threads), WithSeed(3))
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Load()
+				defer w.Unload()
+				TrackFingerprint = true
+				if variant.cfg != nil {
+					variant.cfg()
+				}
+				InitWorld()
+				for i := 0; i < 60; i++ {
+					if err := Update(); err != nil {
+						t.Fatal(err)
+					}
+					if got, want := TickFingerprint(), fingerprint(); got != want {
+						t.Fatalf("tick %d: rolling %016x, full scan %016x", Tick, got, want)
+					}
+				}
+				if !rollingValid {
+					t.Error("the rolling fingerprint was never used")
+				}
+			})
+		}
+	}
+}
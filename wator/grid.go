@@ -0,0 +1,86 @@
+package wator
+
+/// @file grid.go
+/// @brief Grid storage and the runtime grid size.
+/// @details Every per-cell array (cells, timers, school sizes and the
+/// scratch arrays of the optional features) is a "plane": one flat slice
+/// of `Width*Height` values in row-major order, cell (x, y) at index
+/// y*Width+x (`Index()`). The worklists (sparse.go) and claims (claim.go)
+/// use the same index. The tiles of `Update()` scan row by row, so a scan
+/// walks each plane as one sequential stream, and a creature's east and
+/// west neighbors are the adjacent values.
+///
+/// The size is 400x400 unless `SetSize()` picks another one before the
+/// world is built. Planes allocated on demand (`-fingerprint`,
+/// `-shark-vision`) follow a size change by themselves; zones and the
+/// `-summary-png` counts are rebuilt by `SetSize()`.
+
+import "fmt"
+
+// / @brief Largest grid side; the RLE and state formats store sizes as uint16.
+const maxSide = 65535
+
+// / @brief Allocate a zeroed `w` x `h` plane.
+func newPlane[T any](w, h int) []T {
+	return make([]T, w*h)
+}
+
+// / @brief Allocate a zeroed plane of the current grid size.
+func NewPlane[T any]() []T {
+	return newPlane[T](Width, Height)
+}
+
+// / @brief Index of cell (x, y) in a plane of the current grid size.
+func Index(x, y int) int {
+	return y*Width + x
+}
+
+// / @brief Copy the values of `src` into `dst`, which has the same size.
+func CopyPlane[T any](dst, src []T) {
+	copy(dst, src)
+}
+
+// / @brief Whether `p` is missing or was allocated for another grid size.
+func stalePlane[T any](p []T) bool {
+	return len(p) != Width*Height
+}
+
+// / @brief Reject grid sides outside [1, `maxSide`].
+func checkSize(w, h int) error {
+	if w < 1 || h < 1 || w > maxSide || h > maxSide {
+		return fmt.Errorf("grid size must be between 1x1 and %dx%d, got %dx%d", maxSide, maxSide, w, h)
+	}
+	return nil
+}
+
+// / @brief Change the grid size and reallocate the world, which is left
+// / empty.
+// / @details Call it before `InitWorld()` (or loading a state); the
+// / previous world is discarded. Installed zones are checked against the
+// / new size and rebuilt, and `-summary-png` counting starts over.
+// / @return error Non-nil if a side is outside [1, 65535] or an installed
+// / zone does not fit; the old size is kept then.
+func SetSize(w, h int) error {
+	if err := checkSize(w, h); err != nil {
+		return err
+	}
+	oldW, oldH := Width, Height
+	Width, Height = w, h
+	if len(Zones) > 0 {
+		if err := SetZones(Zones); err != nil {
+			Width, Height = oldW, oldH
+			return err
+		}
+	}
+
+	Grid, buffer = newPlane[uint8](w, h), newPlane[uint8](w, h)
+	BreedTimer, bufferBreed = newPlane[int](w, h), newPlane[int](w, h)
+	StarveTimer, bufferStarve = newPlane[int](w, h), newPlane[int](w, h)
+	StackCount, bufferStack = newPlane[uint8](w, h), newPlane[uint8](w, h)
+	if occupancy != nil {
+		EnableSummary()
+	}
+	Tick = 0
+	InvalidateWorklist()
+	return nil
+}
@@ -0,0 +1,58 @@
+package wator
+
+/// @file hex.go
+/// @brief Hexagonal grid topology, selected with `-topology hex`.
+/// @details The grid keeps its planes (grid.go); only the meaning
+/// of "neighbor" changes. Cells are laid out in rows (y) with every odd
+/// row shifted right by half a cell ("odd-r" offset coordinates), so each
+/// cell touches six others: two in its own row and two in each of the rows
+/// above and below. Which two depends on the row's parity:
+///
+///	even y: (-1,-1) ( 0,-1)  (-1, 0) (+1, 0)  (-1,+1) ( 0,+1)
+///	odd y:  ( 0,-1) (+1,-1)  (-1, 0) (+1, 0)  ( 0,+1) (+1,+1)
+///
+/// Wrapping at the edges keeps the torus consistent because `Height` is
+/// even: row 0 is even and its upper neighbors lie in the odd last row.
+/// Fish and sharks pick from the six neighbors exactly like from the four
+/// of the square grid, and a shark claims all six neighbors at once. The
+/// window draws odd rows half a cell to the right, so every cell borders
+/// its six neighbors on screen; at one pixel per cell that is as close to
+/// a hexagon as a cell gets. `-shark-vision` above 1 steers
+/// by square distances and is not available here, and `-tile-order
+/// checkerboard` no longer keeps neighbors out of the same pass.
+
+import "fmt"
+
+const (
+	TopoSquare = "square"
+	TopoHex    = "hex"
+)
+
+// / @brief Grid topology, set with `-topology`.
+var Topology string = TopoSquare
+
+// / @brief Validate and select the topology.
+// / @param name One of "square" or "hex".
+// / @return error Non-nil for an unknown topology.
+func SetTopology(name string) error {
+	switch name {
+	case TopoSquare, TopoHex:
+		Topology = name
+		return nil
+	}
+	return fmt.Errorf("unknown topology %q (want %s or %s)", name, TopoSquare, TopoHex)
+}
+
+// / @brief The (dx, dy) offsets of the neighbors of a cell in row `y`.
+// / @details Square: W, E, N, S. Hex: the six neighbors of the row's parity,
+// / clockwise from the upper right.
+// / @return [][2]int A fresh slice the caller may reorder.
+func neighborOffsets(y int) [][2]int {
+	if Topology != TopoHex {
+		return [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	}
+	if y%2 == 0 {
+		return [][2]int{{0, -1}, {1, 0}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}}
+	}
+	return [][2]int{{1, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 0}, {0, -1}}
+}
@@ -0,0 +1,86 @@
+package wator
+
+/// @file hunt.go
+/// @brief Shark hunting strategies.
+/// @details In the default `random` mode a shark eats the first fish found
+/// in its shuffled direction order. In `greedy` mode the shark prefers the
+/// neighboring fish whose cell has the most fish around it, so it moves
+/// into the densest part of a school.
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	HuntRandom = "random"
+	HuntGreedy = "greedy"
+)
+
+// / @brief Active hunting strategy, set with `-shark-hunt`.
+var SharkHunt string = HuntRandom
+
+// / @brief Validate and select a hunting strategy.
+// / @param mode Either "random" or "greedy".
+// / @return error Non-nil for an unknown mode.
+func SetSharkHunt(mode string) error {
+	switch mode {
+	case HuntRandom, HuntGreedy:
+		SharkHunt = mode
+		return nil
+	}
+	return fmt.Errorf("unknown shark hunt mode %q (want %s or %s)", mode, HuntRandom, HuntGreedy)
+}
+
+// / @brief Count the fish in the neighbors of a cell (four, or six on the hex
+// / grid) at the start of the tick.
+// / @param x Cell x coordinate.
+// / @param y Cell y coordinate.
+// / @return int Number of neighboring cells holding a fish.
+func fishNeighbors(x, y int) int {
+	n := 0
+	for _, dir := range neighborOffsets(y) {
+		if visionGrid[Index((x+dir[0]+Width)%Width, (y+dir[1]+Height)%Height)] == 1 {
+			n++
+		}
+	}
+	return n
+}
+
+// / @brief Reorder a shark's directions so the best prey comes first.
+// / @details Directions leading to a fish are ranked by how many fish
+// / surround that fish; directions without a fish keep their relative order
+// / after them. The sort is stable, so ties are broken by the incoming order.
+// / The scores are only a preference, read from the start-of-tick copy of
+// / the grid sharks also look at with `-shark-vision` (vision.go), since
+// / another worker may be writing the cells of `Grid` around its tile: the
+// / eat loop re-checks each target under its cell claim, so a fish taken in
+// / the meantime just falls through to the next candidate.
+// / @param x Shark x coordinate.
+// / @param y Shark y coordinate.
+// / @param directions Candidate directions, reordered in place.
+func rankPrey(x, y int, directions [][2]int) {
+	score := make([]int, len(directions))
+	for i, dir := range directions {
+		nx := (x + dir[0] + Width) % Width
+		ny := (y + dir[1] + Height) % Height
+		score[i] = -1
+		if visionGrid[Index(nx, ny)] == 1 {
+			score[i] = fishNeighbors(nx, ny)
+		}
+	}
+	sort.Stable(byScore{directions, score})
+}
+
+// / @brief sort.Interface ordering directions by descending score.
+type byScore struct {
+	dirs  [][2]int
+	score []int
+}
+
+func (b byScore) Len() int           { return len(b.dirs) }
+func (b byScore) Less(i, j int) bool { return b.score[i] > b.score[j] }
+func (b byScore) Swap(i, j int) {
+	b.dirs[i], b.dirs[j] = b.dirs[j], b.dirs[i]
+	b.score[i], b.score[j] = b.score[j], b.score[i]
+}
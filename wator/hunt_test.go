@@ -0,0 +1,43 @@
+package wator
+
+import "testing"
+
+// / @brief The shark at (3, 1) has two fish next to it: the one to the east
+// / stands alone, the one to the south has two fish around it.
+const huntLayout = `
+.......
+...Sf..
+..ff...
+...f...
+.......
+.......`
+
+// / @brief `rankPrey` puts the direction of the best-surrounded fish first
+// / and keeps the others in their order.
+func TestRankPrey(t *testing.T) {
+	newTestWorld(t, huntLayout, nil)
+	prepareVision()
+	dirs := [][2]int{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+	rankPrey(3, 1, dirs)
+	want := [][2]int{{0, 1}, {1, 0}, {0, -1}, {-1, 0}}
+	for i := range want {
+		if dirs[i] != want[i] {
+			t.Fatalf("ranked directions %v, want %v", dirs, want)
+		}
+	}
+}
+
+// / @brief With the fixed N, E, S, W order a random-mode shark eats the
+// / first fish it finds, east; a greedy one eats the southern one.
+func TestGreedyTarget(t *testing.T) {
+	for _, tc := range []struct {
+		hunt string
+		x, y int
+	}{{HuntRandom, 4, 1}, {HuntGreedy, 3, 2}} {
+		w := newTestWorld(t, huntLayout, func() { Deterministic, SharkHunt = true, tc.hunt })
+		step(t, w, 1)
+		if c := w.Cell(tc.x, tc.y); c.State != 2 || c.Starve != SharkStarve {
+			t.Errorf("%s: (%d, %d) holds %+v, want the fed shark", tc.hunt, tc.x, tc.y, c)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package wator
+
+/// @file iter.go
+/// @brief Read access to the grid for analyzers and exporters.
+/// @details These functions read `Grid` and the timer arrays directly,
+/// without copying and without locks; only `Neighborhood` allocates, a
+/// slice of four or six states. They must only be called between ticks
+/// (never concurrently with `Update()`), and callbacks must not modify the
+/// world while iterating. The world is always a torus, so `CellAt` and
+/// `Neighborhood` wrap coordinates at the edges. Creatures have no age or
+/// energy beyond their breed and starve timers.
+
+// / @brief Call `fn` for every cell, row by row.
+// / @param fn Receives the cell coordinates and state (0 empty, 1 fish, 2 shark).
+func ForEachCell(fn func(x, y int, state uint8)) {
+	for i, s := range Grid {
+		fn(i%Width, i/Width, s)
+	}
+}
+
+// / @brief Call `fn` for every fish and shark, row by row.
+// / @param fn Receives the coordinates, state and the creature's breed and
+// / starve timers (starve is 0 for fish unless `FishStarve` is set).
+func ForEachCreature(fn func(x, y int, state uint8, breed, starve int)) {
+	for i, s := range Grid {
+		if s != 0 {
+			fn(i%Width, i/Width, s, BreedTimer[i], StarveTimer[i])
+		}
+	}
+}
+
+// / @brief Everything known about a single cell.
+type CellInfo struct {
+	State  uint8 // 0 empty, 1 fish, 2 shark
+	Breed  int   // ticks until the creature breeds (0 for empty cells)
+	Starve int   // ticks until it starves (0 for empty cells and non-starving fish)
+	School int   // fish in the cell: 1, or the school size with `-stacking` (0 for other cells)
+}
+
+// / @brief Wrap a coordinate pair onto the torus.
+func wrapCoords(x, y int) (int, int) {
+	return ((x % Width) + Width) % Width, ((y % Height) + Height) % Height
+}
+
+// / @brief Inspect one cell; same calling rules as `ForEachCell`.
+// / @details Coordinates outside the grid wrap around, since the world is a
+// / torus.
+// / @return CellInfo The cell's state, timers and school size.
+func CellAt(x, y int) CellInfo {
+	i := Index(wrapCoords(x, y))
+	if Grid[i] == 0 {
+		return CellInfo{}
+	}
+	c := CellInfo{State: Grid[i], Breed: BreedTimer[i], Starve: StarveTimer[i]}
+	if c.State == 1 {
+		c.School = fishIn(i)
+	}
+	return c
+}
+
+// / @brief States of the neighbors creatures at (x, y) move to.
+// / @details In the order of `neighborOffsets()`: W, E, N, S on the square
+// / grid, and with `-topology hex` the six neighbors of the row's parity,
+// / clockwise from the upper right (see hex.go). Wraps around the grid
+// / edges like the simulation does.
+// / @return []uint8 A fresh slice of four or six states.
+func Neighborhood(x, y int) []uint8 {
+	x, y = wrapCoords(x, y)
+	offsets := neighborOffsets(y)
+	n := make([]uint8, len(offsets))
+	for i, d := range offsets {
+		n[i] = Grid[Index(wrapCoords(x+d[0], y+d[1]))]
+	}
+	return n
+}
@@ -0,0 +1,112 @@
+package wator
+
+import (
+	"slices"
+	"testing"
+)
+
+// / @brief `Neighborhood` lists the cells `neighborOffsets` names, wrapped
+// / onto the torus, at corners of the square and the hex grid.
+func TestNeighborhoodWraps(t *testing.T) {
+	empty := `
+......
+......
+......
+......`
+	for _, tc := range []struct {
+		name string
+		topo string
+		x, y int
+		want [][2]int // the neighbors in order
+	}{
+		{"square top-left", TopoSquare, 0, 0, [][2]int{{5, 0}, {1, 0}, {0, 3}, {0, 1}}},
+		{"square bottom-right", TopoSquare, 5, 3, [][2]int{{4, 3}, {0, 3}, {5, 2}, {5, 0}}},
+		{"hex even row", TopoHex, 0, 0, [][2]int{{0, 3}, {1, 0}, {0, 1}, {5, 1}, {5, 0}, {5, 3}}},
+		{"hex odd row", TopoHex, 5, 3, [][2]int{{0, 2}, {0, 3}, {0, 0}, {5, 0}, {4, 3}, {5, 2}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			newTestWorld(t, empty, func() { SetTopology(tc.topo) })
+			for k, c := range tc.want {
+				SetCell(c[0], c[1], 1)
+				want := make([]uint8, len(tc.want))
+				want[k] = 1
+				if got := Neighborhood(tc.x, tc.y); !slices.Equal(got, want) {
+					t.Errorf("fish at (%d, %d): Neighborhood(%d, %d) = %v, want %v", c[0], c[1], tc.x, tc.y, got, want)
+				}
+				// the same cell, given outside the grid
+				if got := Neighborhood(tc.x-Width, tc.y+Height); !slices.Equal(got, want) {
+					t.Errorf("fish at (%d, %d): wrapped Neighborhood = %v, want %v", c[0], c[1], got, want)
+				}
+				SetCell(c[0], c[1], 0)
+			}
+		})
+	}
+}
+
+// / @brief The iterators and `CellAt` see creatures in the four corners at
+// / the right coordinates, with their timers; `CellAt` wraps onto them.
+func TestIterCorners(t *testing.T) {
+	newTestWorld(t, `
+f...S
+.....
+S...f`, func() { FishBreed, SharkBreed, SharkStarve = 3, 7, 4 })
+	corners := map[[2]int]CellInfo{
+		{0, 0}: {State: 1, Breed: 3, School: 1},
+		{4, 0}: {State: 2, Breed: 7, Starve: 4},
+		{0, 2}: {State: 2, Breed: 7, Starve: 4},
+		{4, 2}: {State: 1, Breed: 3, School: 1},
+	}
+
+	var visited [][2]int
+	ForEachCell(func(x, y int, state uint8) {
+		if len(visited) == 0 && (x != 0 || y != 0) {
+			t.Errorf("ForEachCell starts at (%d, %d), want (0, 0)", x, y)
+		}
+		visited = append(visited, [2]int{x, y})
+		if want := corners[[2]int{x, y}].State; state != want {
+			t.Errorf("ForEachCell: (%d, %d) has state %d, want %d", x, y, state, want)
+		}
+	})
+	if n := len(visited); n != 15 || visited[n-1] != [2]int{4, 2} {
+		t.Errorf("ForEachCell visited %d cells ending at %v, want 15 ending at (4, 2)", n, visited[n-1])
+	}
+
+	seen := 0
+	ForEachCreature(func(x, y int, state uint8, breed, starve int) {
+		want, ok := corners[[2]int{x, y}]
+		if !ok || state != want.State || breed != want.Breed || starve != want.Starve {
+			t.Errorf("ForEachCreature: (%d, %d) state %d breed %d starve %d, want %+v", x, y, state, breed, starve, want)
+		}
+		seen++
+	})
+	if seen != len(corners) {
+		t.Errorf("ForEachCreature saw %d creatures, want %d", seen, len(corners))
+	}
+
+	for c, want := range corners {
+		if got := CellAt(c[0], c[1]); got != want {
+			t.Errorf("CellAt(%d, %d) = %+v, want %+v", c[0], c[1], got, want)
+		}
+		// one grid size further in both directions
+		if got := CellAt(c[0]-Width, c[1]+Height); got != want {
+			t.Errorf("CellAt(%d, %d) = %+v, want %+v", c[0]-Width, c[1]+Height, got, want)
+		}
+	}
+	if got := CellAt(-1, -1); got != corners[[2]int{4, 2}] {
+		t.Errorf("CellAt(-1, -1) = %+v, want the bottom-right fish", got)
+	}
+	if got := CellAt(2, 1); got != (CellInfo{}) {
+		t.Errorf("empty cell gives %+v", got)
+	}
+}
+
+// / @brief With `-stacking` `CellAt` reports the size of a school.
+func TestCellAtSchool(t *testing.T) {
+	newTestWorld(t, `
+f.
+..`, func() { Stacking = true })
+	StackCount[Index(0, 0)] = 5
+	if got := CellAt(0, 0).School; got != 5 {
+		t.Errorf("school of 5 reported as %d", got)
+	}
+}
@@ -0,0 +1,68 @@
+package wator
+
+import (
+	"fmt"
+	"testing"
+)
+
+// / @brief `TileLayout()` for several thread counts, schedulers and grid
+// / sizes that do not divide evenly, and its tiles cover every cell once.
+func TestTileLayout(t *testing.T) {
+	for _, tc := range []struct {
+		sched                    string
+		threads, w, h            int
+		cols, rows, tileW, tileH int
+		active                   int
+	}{
+		{SchedStatic, 1, 400, 400, 1, 1, 400, 400, 1},
+		{SchedStatic, 2, 400, 400, 1, 2, 400, 200, 2},
+		{SchedStatic, 4, 400, 400, 2, 2, 200, 200, 4},
+		{SchedStatic, 3, 101, 37, 1, 3, 101, 13, 3},
+		{SchedStatic, 6, 100, 50, 2, 3, 50, 17, 6},
+		{SchedStatic, 5, 10, 4, 2, 3, 5, 2, 4},   // the last row of tiles is empty
+		{SchedStatic, 8, 3, 10, 1, 3, 3, 4, 3},   // capped at one thread per column
+		{SchedQueue, 2, 40, 40, 2, 4, 20, 10, 8}, // TileSplit tiles per thread
+		{SchedQueue, 1, 40, 40, 1, 1, 40, 40, 1},
+		{SchedPhased, 4, 40, 10, 10, 1, 4, 10, 10}, // 12 stripes would leave the last one empty
+		{SchedPhased, 4, 5, 10, 1, 1, 5, 10, 1},    // too narrow for two stripes
+	} {
+		t.Run(fmt.Sprintf("%s/threads=%d/%dx%d", tc.sched, tc.threads, tc.w, tc.h), func(t *testing.T) {
+			w, err := NewWorld(WithSize(tc.w, tc.h), WithFish(0), WithSharks(0), WithThreads(tc.threads))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Do(func() {
+				if err := SetScheduler(tc.sched); err != nil {
+					t.Fatal(err)
+				}
+				cols, rows, tileW, tileH := TileLayout()
+				if cols != tc.cols || rows != tc.rows || tileW != tc.tileW || tileH != tc.tileH {
+					t.Errorf("layout %dx%d tiles of %dx%d, want %dx%d of %dx%d",
+						cols, rows, tileW, tileH, tc.cols, tc.rows, tc.tileW, tc.tileH)
+				}
+				if n := CountActiveTiles(cols, rows, tileW, tileH); n != tc.active {
+					t.Errorf("%d active tiles, want %d", n, tc.active)
+				}
+				covered := NewPlane[int]()
+				for tx := 0; tx < cols; tx++ {
+					for ty := 0; ty < rows; ty++ {
+						sx, ex, sy, ey, ok := TileBounds(tx, ty, tileW, tileH)
+						if !ok {
+							continue
+						}
+						for y := sy; y < ey; y++ {
+							for x := sx; x < ex; x++ {
+								covered[Index(x, y)]++
+							}
+						}
+					}
+				}
+				for i, n := range covered {
+					if n != 1 {
+						t.Fatalf("cell (%d,%d) lies in %d tiles", i%Width, i/Width, n)
+					}
+				}
+			})
+		})
+	}
+}
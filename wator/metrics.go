@@ -0,0 +1,51 @@
+package wator
+
+/// @file metrics.go
+/// @brief Per-tick population metrics.
+/// @details `CollectStats()` gathers everything recorded about a tick in a
+/// single pass over the grid. New metrics only need a field in `TickStats`.
+
+import "fmt"
+
+// / @brief Metrics of one tick.
+type TickStats struct {
+	Tick   int `json:"tick"`
+	Fish   int `json:"fish"`
+	Sharks int `json:"sharks"`
+
+	// tile run times in microseconds, with -tile-timing only
+	TileMinUS  int64 `json:"tile_min_us,omitempty"`
+	TileMeanUS int64 `json:"tile_mean_us,omitempty"`
+	TileMaxUS  int64 `json:"tile_max_us,omitempty"`
+
+	// state fingerprint in hex, with -fingerprint only
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// spatial clustering in [-1, 1], with -clustering only
+	Clustering *float64 `json:"clustering,omitempty"`
+}
+
+// / @brief Gather the metrics for the current state.
+func CollectStats() TickStats {
+	st := TickStats{Tick: Tick}
+	if TileTiming {
+		min, mean, max := tileTimeStats()
+		st.TileMinUS, st.TileMeanUS, st.TileMaxUS = min.Microseconds(), mean.Microseconds(), max.Microseconds()
+	}
+	if TrackFingerprint {
+		st.Fingerprint = fmt.Sprintf("%016x", TickFingerprint())
+	}
+	if ClusterMetric {
+		c := clustering(Grid)
+		st.Clustering = &c
+	}
+	for i, s := range Grid {
+		switch s {
+		case 1:
+			st.Fish += fishIn(i)
+		case 2:
+			st.Sharks++
+		}
+	}
+	return st
+}
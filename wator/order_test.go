@@ -0,0 +1,56 @@
+package wator
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// / @brief Shift of the center of a 40x40 block of non-breeding fish over 30
+// / ticks.
+func blockDrift(t *testing.T, order string) (dx, dy float64) {
+	t.Helper()
+	var b strings.Builder
+	for y := 0; y < 120; y++ {
+		for x := 0; x < 120; x++ {
+			if x >= 40 && x < 80 && y >= 40 && y < 80 {
+				b.WriteByte('f')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	w := newTestWorld(t, b.String(), func() {
+		NoFishBreed = true
+		if err := SetTileOrder(order); err != nil {
+			t.Fatal(err)
+		}
+	})
+	center := func() (cx, cy float64) {
+		ForEachCreature(func(x, y int, _ uint8, _, _ int) {
+			cx += float64(x)
+			cy += float64(y)
+		})
+		return cx / 1600, cy / 1600
+	}
+	x0, y0 := center()
+	step(t, w, 30)
+	x1, y1 := center()
+	return x1 - x0, y1 - y0
+}
+
+// / @brief The row-by-row scan lets a symmetric block drift towards lower
+// / x, by about 15 cells in 30 ticks; the random order removes the drift
+// / and the checkerboard removes it along x (see README.md).
+func TestTileOrderDrift(t *testing.T) {
+	if dx, dy := blockDrift(t, OrderScan); dx > -5 {
+		t.Errorf("scan: block moved by (%.2f, %.2f), want a clear drift towards lower x", dx, dy)
+	}
+	if dx, dy := blockDrift(t, OrderRandom); math.Abs(dx) > 0.5 || math.Abs(dy) > 0.5 {
+		t.Errorf("random: block moved by (%.2f, %.2f), want no drift", dx, dy)
+	}
+	if dx, dy := blockDrift(t, OrderChecker); math.Abs(dx) > 2 {
+		t.Errorf("checkerboard: block moved by (%.2f, %.2f), want no drift along x", dx, dy)
+	}
+}
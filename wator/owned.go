@@ -0,0 +1,53 @@
+package wator
+
+/// @file owned.go
+/// @brief Outboxes of the `owned` scheduler: border cells merged serially.
+/// @details With `-scheduler owned` every tile belongs to one worker
+/// outright. A creature that lies two or more cells inside its tile only
+/// reads and writes cells of that tile (it writes one neighbor and reads
+/// at most two cells away, see sched.go), so the workers run those without
+/// any claims. The creatures in the two-cell border, which could reach
+/// into a neighboring tile, are not touched in parallel: each tile puts
+/// them into its outbox, in the order it visits them, and once all tiles
+/// are done `mergeOutboxes()` runs them on the calling goroutine, tile by
+/// tile in id order, with the tile's own step function and random source.
+/// Nothing then depends on how the workers were scheduled, so with the
+/// per-tile generators (`pcg` unless `-rng tile` or `cell` is given) a
+/// seed repeats exactly for a given `Threads`; the layout, and so the
+/// result, changes with the thread count. The merge pass is serial, so
+/// it costs about as much as the border is large: little for a few big
+/// tiles, everything for tiles under five cells wide.
+
+// / @brief Border cells (y*Width+x) each tile left for the merge pass.
+var outbox [][]int32
+
+// / @brief Each tile's step function of this tick, for the merge pass.
+var outboxStep []func(x, y int)
+
+// / @brief True while `mergeOutboxes()` runs the border cells.
+var merging bool = false
+
+// / @brief Empty the outboxes before a tick.
+// / @param n Number of tiles.
+func prepareOutboxes(n int) {
+	if len(outbox) != n {
+		outbox = make([][]int32, n)
+		outboxStep = make([]func(x, y int), n)
+	}
+	for i := range outbox {
+		outbox[i] = outbox[i][:0]
+		outboxStep[i] = nil
+	}
+}
+
+// / @brief Run the border cells of every tile, tile by tile; call after
+// / the tiles are done and before the buffers are swapped.
+func mergeOutboxes() {
+	merging = true
+	for id, cells := range outbox {
+		for _, c := range cells {
+			outboxStep[id](int(c)%Width, int(c)/Width)
+		}
+	}
+	merging = false
+}
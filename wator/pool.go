@@ -0,0 +1,53 @@
+package wator
+
+/// @file pool.go
+/// @brief Long-lived worker goroutines shared by every tick.
+/// @details Instead of starting fresh goroutines for the buffer stripes and
+/// the tiles of every tick, `Update()` runs them on `Threads` workers that
+/// live for the whole run and wait on one channel for tasks. `runPool()`
+/// feeds a tick's tasks into it in order and waits for them, so the
+/// channel doubles as the work queue of the `queue` and `phased`
+/// schedulers. `Update()` resizes the pool to the current thread count
+/// before each tick: new workers are started, surplus ones are sent a nil
+/// task, which stops them. Tasks must not submit tasks themselves, since
+/// every worker could then be waiting on the channel.
+
+import "sync"
+
+var poolTasks = make(chan func())
+var poolWorkers int = 0
+
+// / @brief Start or stop workers until the pool holds `n` of them.
+func resizePool(n int) {
+	for ; poolWorkers < n; poolWorkers++ {
+		go poolWorker()
+	}
+	for ; poolWorkers > n; poolWorkers-- {
+		poolTasks <- nil
+	}
+}
+
+// / @brief Body of one pool worker: run tasks until a nil one arrives.
+func poolWorker() {
+	for task := range poolTasks {
+		if task == nil {
+			return
+		}
+		task()
+	}
+}
+
+// / @brief Run `n` tasks on the pool, `task(0)` to `task(n-1)` in that
+// / order of hand-out, and wait until all of them are done.
+func runPool(n int, task func(i int)) {
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		i := i
+		poolTasks <- func() {
+			defer wg.Done()
+			task(i)
+		}
+	}
+	wg.Wait()
+}
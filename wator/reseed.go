@@ -0,0 +1,48 @@
+package wator
+
+/// @file reseed.go
+/// @brief Periodic "rain" of new creatures.
+/// @details With `-reseed-every N`, every N ticks `Update()` adds
+/// `-reseed-fish` fish and `-reseed-sharks` sharks at random empty cells
+/// after the buffer swap. New creatures get the same fresh timers as in
+/// `InitWorld()`. If the grid has fewer empty cells than requested, only as
+/// many creatures as fit are added (fish first).
+
+var ReseedEvery int = 0
+var ReseedFish int = 0
+var ReseedSharks int = 0
+
+// / @brief Add creatures at random empty cells.
+// / @param nFish Number of fish to add.
+// / @param nSharks Number of sharks to add.
+// / @return int Number of creatures actually placed.
+func reseed(nFish, nSharks int) int {
+	if nFish+nSharks == 0 {
+		return 0
+	}
+
+	empty := make([]int, 0, Width*Height)
+	for i, s := range Grid {
+		if s == 0 {
+			empty = append(empty, i)
+		}
+	}
+
+	// partial Fisher-Yates: the first `placed` entries become the targets
+	placed := 0
+	for _, state := range []uint8{1, 2} {
+		n := nFish
+		if state == 2 {
+			n = nSharks
+		}
+		for ; n > 0 && placed < len(empty); n-- {
+			j := placed + rnd.Intn(len(empty)-placed)
+			empty[placed], empty[j] = empty[j], empty[placed]
+			c := empty[placed]
+			spawn(c%Width, c/Width, state)
+			placed++
+		}
+	}
+	InvalidateWorklist()
+	return placed
+}
@@ -0,0 +1,50 @@
+package wator
+
+import (
+	"strings"
+	"testing"
+)
+
+// / @brief Reseeding adds its creatures exactly on every N-th tick.
+// / @details Nothing else changes the counts: nobody breeds, and fish and
+// / sharks are reseeded into separate worlds so no shark eats.
+func TestReseedTicks(t *testing.T) {
+	const every, ticks = 5, 23
+	empty := strings.Repeat(strings.Repeat(".", 20)+"\n", 20)
+	for _, tc := range []struct {
+		name         string
+		fish, sharks int
+	}{{"fish", 7, 0}, {"sharks", 0, 3}} {
+		w := newTestWorld(t, empty, func() {
+			NoFishBreed, NoSharkBreed, SharkStarve = true, true, 1000
+			ReseedEvery, ReseedFish, ReseedSharks = every, tc.fish, tc.sharks
+		})
+		// Update, since Step stops at the empty, extinct world
+		for tick := 1; tick <= ticks; tick++ {
+			if err := Update(); err != nil {
+				t.Fatal(err)
+			}
+			rounds := tick / every
+			if f, s := w.Fish(), w.Sharks(); f != rounds*tc.fish || s != rounds*tc.sharks {
+				t.Fatalf("%s: tick %d: %d fish, %d sharks, want %d and %d", tc.name, tick, f, s, rounds*tc.fish, rounds*tc.sharks)
+			}
+		}
+	}
+}
+
+// / @brief A reseed that does not fit fills the empty cells, fish first.
+func TestReseedFull(t *testing.T) {
+	w := newTestWorld(t, "...\n...\n...", func() {
+		NoFishBreed, NoSharkBreed = true, true
+		ReseedEvery, ReseedFish, ReseedSharks = 1, 6, 6
+	})
+	if n := reseed(ReseedFish, ReseedSharks); n != 9 {
+		t.Errorf("placed %d creatures on 9 empty cells", n)
+	}
+	if f, s := w.Fish(), w.Sharks(); f != 6 || s != 3 {
+		t.Errorf("%d fish, %d sharks, want 6 and 3", f, s)
+	}
+	if n := reseed(1, 1); n != 0 {
+		t.Errorf("placed %d creatures on a full grid", n)
+	}
+}
@@ -0,0 +1,100 @@
+package wator
+
+/// @file rle.go
+/// @brief Compact run-length-encoded snapshot of the cell states.
+/// @details Wire format (all integers little-endian / unsigned varint as
+/// produced by encoding/binary):
+///
+///	offset 0  uint16  grid width
+///	offset 2  uint16  grid height
+///	offset 4  runs... until the end of the data
+///
+/// Each run is one state byte (0 empty, 1 fish, 2 shark) followed by the
+/// run length as a uvarint. Cells are visited in row-major order: y from 0
+/// to height-1, and x from 0 to width-1 inside each row. The run lengths
+/// must add up to exactly width*height. Only cell states are encoded; the
+/// breed/starve timers are not part of the snapshot.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// / @brief Encode the current `Grid` as an RLE snapshot.
+// / @return []byte The encoded snapshot (see the file comment for the format).
+func EncodeRLE() []byte {
+	out := make([]byte, 4, 64)
+	binary.LittleEndian.PutUint16(out[0:], uint16(Width))
+	binary.LittleEndian.PutUint16(out[2:], uint16(Height))
+
+	var tmp [binary.MaxVarintLen64]byte
+	run := uint64(0)
+	var cur uint8
+	for _, c := range Grid {
+		if run > 0 && c == cur {
+			run++
+			continue
+		}
+		if run > 0 {
+			out = append(out, cur)
+			out = append(out, tmp[:binary.PutUvarint(tmp[:], run)]...)
+		}
+		cur, run = c, 1
+	}
+	out = append(out, cur)
+	out = append(out, tmp[:binary.PutUvarint(tmp[:], run)]...)
+	return out
+}
+
+// / @brief Replace `Grid` with the cells from an RLE snapshot.
+// / @details The data is fully validated before `Grid` is touched. Timers
+// / are reset the same way `InitWorld()` sets them for new creatures.
+// / @param data Snapshot produced by `EncodeRLE()`.
+// / @return error Non-nil if the data is malformed or its size differs from the grid.
+func DecodeRLE(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("rle: snapshot too short")
+	}
+	w := int(binary.LittleEndian.Uint16(data[0:]))
+	h := int(binary.LittleEndian.Uint16(data[2:]))
+	if w != Width || h != Height {
+		return fmt.Errorf("rle: snapshot is %dx%d, grid is %dx%d", w, h, Width, Height)
+	}
+
+	cells := make([]uint8, 0, w*h)
+	rest := data[4:]
+	for len(rest) > 0 {
+		state := rest[0]
+		if state > 2 {
+			return fmt.Errorf("rle: invalid cell state %d", state)
+		}
+		n, k := binary.Uvarint(rest[1:])
+		if k <= 0 {
+			return errors.New("rle: truncated run length")
+		}
+		if n == 0 || n > uint64(w*h-len(cells)) {
+			return fmt.Errorf("rle: run of %d cells does not fit the grid", n)
+		}
+		for i := uint64(0); i < n; i++ {
+			cells = append(cells, state)
+		}
+		rest = rest[1+k:]
+	}
+	if len(cells) != w*h {
+		return fmt.Errorf("rle: snapshot holds %d cells, want %d", len(cells), w*h)
+	}
+
+	InvalidateWorklist()
+	clear(Grid)
+	clear(BreedTimer)
+	clear(StarveTimer)
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			if c := cells[Index(x, y)]; c != 0 {
+				spawn(x, y, c)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package wator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// / @brief The text form of the loaded grid.
+func gridText() string {
+	var b strings.Builder
+	WriteText(&b)
+	return b.String()
+}
+
+// / @brief Decoding an encoded grid gives the same cells, for random worlds
+// / and grids made of one long run.
+func TestRLERoundTrip(t *testing.T) {
+	for _, layout := range []string{"....\n....\n....", "ffff\nffff\nffff", "SSSS\nSSSS\nSSS.", "f...\n...S\n.f.."} {
+		newTestWorld(t, layout, nil)
+		data := EncodeRLE()
+		want := gridText()
+		clear(Grid)
+		if err := DecodeRLE(data); err != nil {
+			t.Fatalf("%q: %v", layout, err)
+		}
+		if got := gridText(); got != want {
+			t.Errorf("%q decodes to\n%s", layout, got)
+		}
+	}
+
+	w, err := NewWorld(WithSize(50, 40), WithFish(300), WithSharks(80), WithSeed(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Load()
+	defer w.Unload()
+	step(t, w, 5)
+	want := gridText()
+	data := EncodeRLE()
+	w.Reset()
+	if err := DecodeRLE(data); err != nil {
+		t.Fatal(err)
+	}
+	if got := gridText(); got != want {
+		t.Error("random world does not survive the round trip")
+	}
+}
+
+// / @brief The runs of a small grid, byte for byte.
+func TestRLEEncoding(t *testing.T) {
+	newTestWorld(t, "f.\n.S", nil)
+	want := []byte{2, 0, 2, 0, 1, 1, 0, 2, 2, 1}
+	if got := EncodeRLE(); !bytes.Equal(got, want) {
+		t.Errorf("EncodeRLE() = %v, want %v", got, want)
+	}
+}
+
+// / @brief Malformed snapshots are rejected and leave the grid alone.
+func TestDecodeRLEInvalid(t *testing.T) {
+	newTestWorld(t, "f.\n.S", nil)
+	before := gridText()
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"short header", []byte{2, 0, 2}},
+		{"other size", []byte{3, 0, 2, 0, 0, 6}},
+		{"invalid state", []byte{2, 0, 2, 0, 3, 4}},
+		{"truncated run", []byte{2, 0, 2, 0, 1}},
+		{"zero run", []byte{2, 0, 2, 0, 0, 0, 1, 4}},
+		{"run too long", []byte{2, 0, 2, 0, 0, 5}},
+		{"too few cells", []byte{2, 0, 2, 0, 0, 3}},
+	} {
+		if err := DecodeRLE(tc.data); err == nil {
+			t.Errorf("%s: no error", tc.name)
+		}
+		if got := gridText(); got != before {
+			t.Errorf("%s: grid changed to\n%s", tc.name, got)
+		}
+	}
+}
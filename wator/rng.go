@@ -0,0 +1,330 @@
+package wator
+
+/// @file rng.go
+/// @brief Selectable random number source for the update step.
+/// @details `-rng math` (default) draws from the package's `math/rand`
+/// source, seeded with `Seed()`; it yields the same numbers as the global
+/// source seeded with `rand.Seed()`. It is shared by all tile goroutines
+/// behind a mutex, so the numbers a tile gets depend on how the goroutines
+/// interleave, and heavy use contends on the lock.
+///
+/// `-rng pcg` gives every tile its own PCG32 generator (O'Neill's
+/// PCG-XSH-RR 64/32) for the tick. Each generator is seeded from the global
+/// source before the goroutines start, with the tile index as its stream,
+/// so a run is still fully determined by the global seed, a tile's draws do
+/// not depend on the other tiles, and drawing needs no lock. The global
+/// source itself, which builds the world, reseeds and seeds the tiles, is
+/// then a PCG32 stream too (`masterStream`) behind `math/rand`'s `Rand`,
+/// whose methods are frozen for compatibility, so a pcg run no longer
+/// depends on `math/rand`'s generator and repeats across Go versions and,
+/// with `-scheduler phased`, thread counts. PCG32 has good
+/// statistical quality and is cheap, but it is not cryptographically secure
+/// and produces different sequences than `math/rand`, so results differ
+/// from default runs with the same seed. `-scheduler phased` and `owned`
+/// (sched.go) draw this way unless `-rng tile` is given.
+///
+/// `-rng tile` also gives every tile its own generator, but a `math/rand`
+/// one: each tick it is reseeded with a value drawn from the global source
+/// in tile order, so like `pcg` the run is determined by the global seed
+/// and the tiles draw without a lock, and the generators are the familiar
+/// `rand.Rand` (kept per tile and reseeded in place, so nothing is
+/// allocated per tick). Reseeding a `math/rand` source takes about ten
+/// microseconds, little next to a tick with a handful of tiles, but it
+/// adds up with the dozens of tiles of `-scheduler queue` or `phased`,
+/// where `pcg` is the cheaper choice. Its sequences differ from both
+/// `math` and `pcg` runs with the same seed.
+///
+/// `-rng cell` restarts a PCG32 for every creature from
+/// `hash(seed, tick, x, y)`, so the directions and other choices a creature
+/// draws depend only on where and when it is, not on which tile it lies
+/// in, in which order the tile is visited or what the creatures before it
+/// drew. Moving the tile borders (another `-threads` or scheduler) or
+/// `-tile-order` then changes only the claims on contested cells, never
+/// the random choices themselves. Nothing is drawn from the global source
+/// during a tick; the streams follow from the seed and the tick alone.
+///
+/// The source also counts the values it hands out. `math/rand` cannot
+/// export a generator's internal state, but the state is fully determined
+/// by the seed and the number of draws since, which is what a state file
+/// records (see `SaveState()`); `LoadState()` reseeds and skips that many
+/// values to continue the exact sequence. A PCG32 can jump ahead by any
+/// number of steps in O(log n) (Brown's LCG skip-ahead), so a pcg source
+/// is restored at once however long the run was. A `math/rand` source has
+/// to draw every value again, about a second per billion, so states with
+/// more than `maxRestoreDraws` draws are rejected for it rather than
+/// hanging the loader.
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	RNGMath = "math"
+	RNGPCG  = "pcg"
+	RNGTile = "tile"
+	RNGCell = "cell"
+)
+
+// / @brief Active random source, set with `-rng`.
+var rngSource string = RNGMath
+
+// / @brief Validate and select a random source.
+// / @param name One of "math", "pcg", "tile" or "cell".
+// / @return error Non-nil for an unknown source.
+func SetRNG(name string) error {
+	switch name {
+	case RNGMath, RNGPCG, RNGTile, RNGCell:
+		rngSource = name
+		return nil
+	}
+	return fmt.Errorf("unknown rng %q (want %s, %s, %s or %s)", name, RNGMath, RNGPCG, RNGTile, RNGCell)
+}
+
+// / @brief `math/rand` source that remembers its seed and counts its draws.
+type countedSource struct {
+	mu    sync.Mutex
+	src   rand.Source64
+	seed  int64
+	draws uint64
+}
+
+func (s *countedSource) Int63() int64 {
+	s.mu.Lock()
+	s.draws++
+	v := s.src.Int63()
+	s.mu.Unlock()
+	return v
+}
+
+func (s *countedSource) Uint64() uint64 {
+	s.mu.Lock()
+	s.draws++
+	v := s.src.Uint64()
+	s.mu.Unlock()
+	return v
+}
+
+// / @details Picks the generator of the active `-rng`, so `SetRNG()` takes
+// / effect with the next `Seed()`.
+func (s *countedSource) Seed(seed int64) {
+	s.mu.Lock()
+	if rngSource == RNGPCG {
+		s.src = &pcgSource{newPCG(uint64(seed), masterStream)}
+	} else {
+		s.src = rand.NewSource(seed).(rand.Source64)
+	}
+	s.seed, s.draws = seed, 0
+	s.mu.Unlock()
+}
+
+// / @brief Most draws `restore()` replays one by one for a `math/rand`
+// / source, a few seconds' worth.
+const maxRestoreDraws = 1 << 32
+
+// / @brief Whether `restore()` can skip `draws` values of the generator the
+// / active `-rng` selects.
+func canRestore(draws uint64) error {
+	if rngSource != RNGPCG && draws > maxRestoreDraws {
+		return fmt.Errorf("random source: %d draws to replay, at most %d are supported (use -rng pcg for longer runs)", draws, uint64(maxRestoreDraws))
+	}
+	return nil
+}
+
+// / @brief Reseed and skip `draws` values; every draw advances the
+// / generator by one value, whether it was `Int63()` or `Uint64()`.
+// / @details The caller checks `canRestore()` first.
+func (s *countedSource) restore(seed int64, draws uint64) {
+	s.Seed(seed)
+	s.mu.Lock()
+	if p, ok := s.src.(*pcgSource); ok {
+		// every value takes two steps of the PCG32
+		p.p.advance(2 * draws)
+	} else {
+		for i := uint64(0); i < draws; i++ {
+			s.src.Uint64()
+		}
+	}
+	s.draws = draws
+	s.mu.Unlock()
+}
+
+// / @brief Where the simulation's random numbers come from.
+var source *countedSource = newCountedSource(time.Now().UnixNano())
+var rnd *rand.Rand = rand.New(source)
+
+func newCountedSource(seed int64) *countedSource {
+	s := &countedSource{}
+	s.Seed(seed)
+	return s
+}
+
+// / @brief Seed the simulation's random source.
+// / @details With the same seed and settings (and `Threads` 1 or the
+// / `phased` scheduler), `InitWorld()` and `Update()` repeat the same run.
+// / Until it is called the source is seeded from the clock.
+func Seed(seed int64) {
+	source.Seed(seed)
+}
+
+// / @brief The seed last given to `Seed()`, or restored by `LoadState()`.
+func CurrentSeed() int64 {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	return source.seed
+}
+
+// / @brief The seed and the number of values drawn since it was set.
+func rngState() (int64, uint64) {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	return source.seed, source.draws
+}
+
+// / @brief The random numbers a tile needs during a tick.
+type tileRand interface {
+	Intn(n int) int
+	Float64() float64
+	Shuffle(n int, swap func(i, j int))
+}
+
+// / @brief The package's `math/rand` source.
+type mathRand struct{}
+
+func (mathRand) Intn(n int) int                     { return rnd.Intn(n) }
+func (mathRand) Float64() float64                   { return rnd.Float64() }
+func (mathRand) Shuffle(n int, swap func(i, j int)) { rnd.Shuffle(n, swap) }
+
+// / @brief PCG-XSH-RR generator with 64-bit state and 32-bit output.
+type pcg32 struct {
+	state, inc uint64
+}
+
+// / @brief PCG stream of the global source with `-rng pcg`; tiles use their
+// / index as stream, so this one is far from any of them.
+const masterStream = 0x5851f42d4c957f2d
+
+// / @brief `rand.Source64` drawing 64 bits from two steps of a PCG32.
+type pcgSource struct {
+	p *pcg32
+}
+
+func (s *pcgSource) Uint64() uint64 { return uint64(s.p.Uint32())<<32 | uint64(s.p.Uint32()) }
+func (s *pcgSource) Int63() int64   { return int64(s.Uint64() >> 1) }
+func (s *pcgSource) Seed(seed int64) {
+	s.p = newPCG(uint64(seed), masterStream)
+}
+
+// / @brief Seed a generator; different `seq` values give independent streams.
+func newPCG(seed, seq uint64) *pcg32 {
+	p := &pcg32{inc: seq<<1 | 1}
+	p.reseed(seed)
+	return p
+}
+
+// / @brief Restart the generator from `seed`, keeping its stream.
+func (p *pcg32) reseed(seed uint64) {
+	p.state = 0
+	p.Uint32()
+	p.state += seed
+	p.Uint32()
+}
+
+// / @brief Skip `delta` steps, as many calls of `Uint32()`, in O(log delta).
+// / @details Composes the LCG step with itself by repeated squaring; the
+// / arithmetic wraps like the generator's, so any delta works.
+func (p *pcg32) advance(delta uint64) {
+	accMult, accPlus := uint64(1), uint64(0)
+	curMult, curPlus := uint64(6364136223846793005), p.inc
+	for ; delta > 0; delta >>= 1 {
+		if delta&1 != 0 {
+			accMult *= curMult
+			accPlus = accPlus*curMult + curPlus
+		}
+		curPlus *= curMult + 1
+		curMult *= curMult
+	}
+	p.state = accMult*p.state + accPlus
+}
+
+// / @brief Next 32 random bits.
+func (p *pcg32) Uint32() uint32 {
+	old := p.state
+	p.state = old*6364136223846793005 + p.inc
+	xorshifted := uint32(((old >> 18) ^ old) >> 27)
+	rot := uint32(old >> 59)
+	return xorshifted>>rot | xorshifted<<((-rot)&31)
+}
+
+// / @brief Uniform integer in [0, n) without modulo bias (Lemire's method).
+func (p *pcg32) Intn(n int) int {
+	if n <= 0 {
+		panic("pcg32: invalid argument to Intn")
+	}
+	bound := uint32(n)
+	m := uint64(p.Uint32()) * uint64(bound)
+	if low := uint32(m); low < bound {
+		threshold := -bound % bound
+		for low < threshold {
+			m = uint64(p.Uint32()) * uint64(bound)
+			low = uint32(m)
+		}
+	}
+	return int(m >> 32)
+}
+
+// / @brief Uniform float in [0, 1) with 53 random bits.
+func (p *pcg32) Float64() float64 {
+	bits := uint64(p.Uint32())<<21 ^ uint64(p.Uint32())>>11
+	return float64(bits) / (1 << 53)
+}
+
+// / @brief Fisher-Yates shuffle, like `rand.Shuffle`.
+func (p *pcg32) Shuffle(n int, swap func(i, j int)) {
+	for i := n - 1; i > 0; i-- {
+		swap(i, p.Intn(i+1))
+	}
+}
+
+// / @brief Generator of `-rng cell`: one PCG32 per tile, restarted for
+// / every creature with `at()`.
+type cellRand struct {
+	pcg32
+	base uint64 // hash of the seed and the tick
+}
+
+// / @brief Restart the stream for the creature at (x, y).
+func (c *cellRand) at(x, y int) {
+	c.reseed(mix64(c.base ^ uint64(x)<<32 ^ uint64(y)))
+}
+
+// / @brief Per-tile generators of `-rng tile`, reused across ticks.
+var tileRands []*rand.Rand
+
+// / @brief Random source for tile number `tile` in the coming tick.
+// / @details Called serially before the tile goroutines start, so the
+// / per-tile seeds are drawn from the package source in a fixed order.
+// / The `phased` and `owned` schedulers use pcg instead of the shared
+// / source, since their results must not depend on the order in which
+// / tiles draw.
+func tileRNG(tile int) tileRand {
+	switch {
+	case rngSource == RNGTile:
+		for len(tileRands) <= tile {
+			tileRands = append(tileRands, rand.New(rand.NewSource(0)))
+		}
+		tileRands[tile].Seed(rnd.Int63())
+		return tileRands[tile]
+	case rngSource == RNGCell:
+		// the tile's own draws (the shuffle of -tile-order random) come
+		// before the first creature restarts the stream
+		c := &cellRand{pcg32: pcg32{inc: 1}, base: mix64(uint64(CurrentSeed()) ^ mix64(uint64(Tick)))}
+		c.reseed(mix64(c.base ^ uint64(tile)))
+		return c
+	case rngSource == RNGPCG || Scheduler == SchedPhased || Scheduler == SchedOwned:
+		return newPCG(uint64(rnd.Int63()), uint64(tile))
+	}
+	return mathRand{}
+}
@@ -0,0 +1,141 @@
+package wator
+
+/// @file sched.go
+/// @brief How the tiles of a tick are distributed over goroutines.
+/// @details Every scheduler hands its tiles to the same pool of `Threads`
+/// long-lived workers (pool.go). `static` (default) cuts the grid into as
+/// many tiles as `Threads`, so each worker takes about one tile. With
+/// `queue`, the grid is cut into `TileSplit` times as many, smaller tiles
+/// that the workers pull from the pool's queue one after another, so a
+/// worker that finishes a sparse tile early takes over more of the work
+/// instead of idling while others grind through dense regions.
+///
+/// Both schedulers run the same per-tile code; they differ only in tile
+/// size and in which worker runs a tile. With one thread `queue` keeps a
+/// single tile, so serial runs are identical to `static`.
+///
+/// In both, tiles that run at the same time can claim the same cell at
+/// their common border, and which claim comes first depends on how the
+/// goroutines are scheduled, so multithreaded runs do not repeat. `phased`
+/// makes them repeat bit for bit, for any `Threads`: the grid is cut into
+/// a fixed number of full-height stripes that does not depend on the
+/// thread count (up to `phasedStripes`, an even number, each at least
+/// `phasedMinWidth` cells wide), and a tick runs in two phases, first the
+/// even stripes, then the odd ones, each phase on a pool of `Threads`
+/// workers. A creature writes its own cell and one neighbor and reads at
+/// most two cells away (`-shark-hunt greedy`), so stripes of the same
+/// phase, three or more cells apart, never touch a cell another one of
+/// them reads or writes, and the order in which they run does not matter.
+/// Each stripe also draws from its own generator (see rng.go): a PCG
+/// stream, a `math/rand` one with `-rng tile`, or per creature with
+/// `-rng cell`. Results differ from `static` runs with the same seed, since
+/// the odd stripes see the moves of the even ones, as in a serial scan.
+///
+/// `owned` keeps the `static` tiles but lets no two workers meet: each
+/// updates only the creatures deep inside its tile and leaves the border
+/// ones to a serial merge pass (owned.go). It repeats for a given
+/// `Threads` and draws like `phased`.
+
+import "fmt"
+
+const (
+	SchedStatic = "static"
+	SchedQueue  = "queue"
+	SchedPhased = "phased"
+	SchedOwned  = "owned"
+)
+
+// / @brief Active scheduler, set with `-scheduler`.
+var Scheduler string = SchedStatic
+
+// / @brief Tiles per worker in `queue` mode.
+var TileSplit int = 4
+
+// / @brief Run the tiles one after another on the calling goroutine, in the
+// / order (and phases) the scheduler would start them; used by `-validate`.
+var SerialTiles bool = false
+
+// / @brief Most stripes of the `phased` layout, and their smallest width.
+const phasedStripes = 64
+const phasedMinWidth = 3
+
+// / @brief Validate and select a scheduler.
+// / @param name One of "static", "queue", "phased" or "owned".
+// / @return error Non-nil for an unknown scheduler or a tile split below 1.
+func SetScheduler(name string) error {
+	if TileSplit < 1 {
+		return fmt.Errorf("tile-split must be at least 1, got %d", TileSplit)
+	}
+	switch name {
+	case SchedStatic, SchedQueue, SchedPhased, SchedOwned:
+		Scheduler = name
+		return nil
+	}
+	return fmt.Errorf("unknown scheduler %q (want %s, %s, %s or %s)", name, SchedStatic, SchedQueue, SchedPhased, SchedOwned)
+}
+
+// / @brief Number of tiles to cut the grid into, for `tileLayout()`.
+// / @param thr Number of worker threads.
+func tileCount(thr int) int {
+	if Scheduler == SchedQueue && thr > 1 {
+		return thr * TileSplit
+	}
+	return thr
+}
+
+// / @brief The stripe layout of the `phased` scheduler.
+// / @details The most stripes, up to `phasedStripes`, whose count is even
+// / (so the two ends of the torus are in different phases) and that all
+// / come out at least `phasedMinWidth` wide with the rounded-up tile width
+// / of `TileBounds()`. Grids too narrow for two stripes get a single tile.
+// / @return cols, rows, tileW, tileH As for `tileLayout()`.
+func phasedLayout() (cols, rows, tileW, tileH int) {
+	cols = min(phasedStripes, Width/phasedMinWidth) &^ 1
+	for ; cols >= 2; cols -= 2 {
+		tileW = (Width + cols - 1) / cols
+		if Width-(cols-1)*tileW >= phasedMinWidth {
+			return cols, 1, tileW, Height
+		}
+	}
+	return 1, 1, Width, Height
+}
+
+// / @brief One tile's share of a tick and the code that processes it.
+type tileJob struct {
+	run                    func(sx, ex, sy, ey, tx, ty int, rng tileRand, slot int)
+	sx, ex, sy, ey, tx, ty int
+	rng                    tileRand
+	slot                   int
+}
+
+// / @brief The jobs of the last tick, kept so the next one can reuse the slice.
+var tileJobs []tileJob
+
+// / @brief Run all jobs with the active scheduler and wait for them.
+func runTileJobs(jobs []tileJob) {
+	if Scheduler == SchedPhased {
+		var phases [2][]tileJob
+		for _, j := range jobs {
+			phases[j.tx&1] = append(phases[j.tx&1], j)
+		}
+		runJobQueue(phases[0])
+		runJobQueue(phases[1])
+		return
+	}
+	runJobQueue(jobs)
+}
+
+// / @brief Run jobs on the worker pool (or serially, see `SerialTiles`)
+// / and wait for them.
+func runJobQueue(jobs []tileJob) {
+	if SerialTiles {
+		for _, j := range jobs {
+			j.run(j.sx, j.ex, j.sy, j.ey, j.tx, j.ty, j.rng, j.slot)
+		}
+		return
+	}
+	runPool(len(jobs), func(i int) {
+		j := jobs[i]
+		j.run(j.sx, j.ex, j.sy, j.ey, j.tx, j.ty, j.rng, j.slot)
+	})
+}
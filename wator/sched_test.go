@@ -0,0 +1,61 @@
+package wator
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// / @brief A 400x400 layout whose creatures all sit in its top-left 120x120
+// / block, at about a third fish and a tenth sharks.
+func clusteredLayout() [][]uint8 {
+	r := rand.New(rand.NewSource(1))
+	cells := make([][]uint8, 120)
+	for y := range cells {
+		cells[y] = make([]uint8, 120)
+		for x := range cells[y] {
+			switch p := r.Float64(); {
+			case p < 0.1:
+				cells[y][x] = 2
+			case p < 0.45:
+				cells[y][x] = 1
+			}
+		}
+	}
+	return cells
+}
+
+// / @brief Ticks of a world clustered in one corner under `static`, whose
+// / one tile per worker leaves all but one idle, and `queue`, whose smaller
+// / tiles spread the cluster over the workers. The layout is rebuilt every
+// / 20 ticks so the cluster does not spread out.
+func BenchmarkSchedulerClustered(b *testing.B) {
+	layout := clusteredLayout()
+	for _, sched := range []string{SchedStatic, SchedQueue} {
+		b.Run(fmt.Sprintf("%s/threads=4", sched), func(b *testing.B) {
+			w, err := NewWorld(WithSize(400, 400), WithFish(0), WithSharks(0), WithThreads(4), WithSeed(1))
+			if err != nil {
+				b.Fatal(err)
+			}
+			w.Load()
+			defer w.Unload()
+			if err := SetScheduler(sched); err != nil {
+				b.Fatal(err)
+			}
+			if err := BuildWorld(layout); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if Tick == 20 {
+					b.StopTimer()
+					BuildWorld(layout)
+					b.StartTimer()
+				}
+				if err := Update(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package wator
+
+/// @file setters.go
+/// @brief Validated setters for the parameters that may change while running.
+/// @details Code that embeds the simulation should change parameters
+/// through these instead of assigning the globals. Invalid values are
+/// rejected and leave the old setting in place. Like everything else that
+/// touches the world they must be called between ticks, never concurrently
+/// with `Update()`. New timer values apply to timers set from then on, as
+/// with PUT /config.
+
+import "fmt"
+
+// / @brief Set `*dst` to `v` unless `ValidateConfig()` rejects the result.
+func setParam(dst *int, v int) error {
+	old := *dst
+	*dst = v
+	if err := ValidateConfig(); err != nil {
+		*dst = old
+		return err
+	}
+	return nil
+}
+
+// / @brief Set the ticks before a fish breeds.
+// / @return error Non-nil if `v` is negative.
+func SetFishBreed(v int) error {
+	return setParam(&FishBreed, v)
+}
+
+// / @brief Set the ticks before a shark breeds.
+// / @return error Non-nil if `v` is negative.
+func SetSharkBreed(v int) error {
+	return setParam(&SharkBreed, v)
+}
+
+// / @brief Set the ticks a shark survives without eating.
+// / @return error Non-nil if `v` is negative.
+func SetSharkStarve(v int) error {
+	return setParam(&SharkStarve, v)
+}
+
+// / @brief Set the ticks a fish survives without breeding (0 = never starves).
+// / @return error Non-nil if `v` is negative.
+func SetFishStarve(v int) error {
+	return setParam(&FishStarve, v)
+}
+
+// / @brief Set the number of worker goroutines.
+// / @details The next `Update()` lays out its tiles for the new count
+// / (see `TileLayout()`). Counts above the grid width are capped like in `Update()`.
+// / @return error Non-nil if `n` is below 1.
+func SetThreads(n int) error {
+	if n < 1 {
+		return fmt.Errorf("threads must be at least 1, got %d", n)
+	}
+	Threads = n
+	return nil
+}
@@ -0,0 +1,82 @@
+package wator
+
+import (
+	"runtime"
+	"testing"
+)
+
+// / @brief The timer setters take 0 and positive values and reject negative
+// / ones, keeping the old value.
+func TestTimerSetters(t *testing.T) {
+	w, err := NewWorld(WithSize(20, 10), WithFish(20), WithSharks(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Do(func() {
+		for _, s := range []struct {
+			name string
+			set  func(int) error
+			v    *int
+		}{
+			{"SetFishBreed", SetFishBreed, &FishBreed},
+			{"SetSharkBreed", SetSharkBreed, &SharkBreed},
+			{"SetSharkStarve", SetSharkStarve, &SharkStarve},
+			{"SetFishStarve", SetFishStarve, &FishStarve},
+		} {
+			for _, v := range []int{7, 0} {
+				if err := s.set(v); err != nil || *s.v != v {
+					t.Errorf("%s(%d): error %v, value %d", s.name, v, err, *s.v)
+				}
+			}
+			if err := s.set(-1); err == nil || *s.v != 0 {
+				t.Errorf("%s(-1): error %v, value %d, want an error and the old 0", s.name, err, *s.v)
+			}
+		}
+	})
+}
+
+// / @brief `SetThreads()` rejects counts below 1, and an accepted count
+// / changes the tiles of the next tick but leaves GOMAXPROCS to the program.
+func TestSetThreads(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	w, err := NewWorld(WithSize(40, 40), WithFish(100), WithSharks(30), WithThreads(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Load()
+	defer w.Unload()
+	TileTiming = true
+
+	for _, n := range []int{0, -3} {
+		if err := SetThreads(n); err == nil || Threads != 1 {
+			t.Errorf("SetThreads(%d): error %v, threads %d, want an error and the old 1", n, err, Threads)
+		}
+	}
+	for _, tc := range []struct {
+		threads, cols, rows int
+	}{{4, 2, 2}, {6, 2, 3}, {1, 1, 1}} {
+		if err := SetThreads(tc.threads); err != nil {
+			t.Fatal(err)
+		}
+		if cols, rows, _, _ := TileLayout(); cols != tc.cols || rows != tc.rows {
+			t.Errorf("SetThreads(%d): %dx%d tiles, want %dx%d", tc.threads, cols, rows, tc.cols, tc.rows)
+		}
+		if err := Update(); err != nil {
+			t.Fatal(err)
+		}
+		if len(tileTimes) != tc.cols*tc.rows {
+			t.Errorf("SetThreads(%d): the next tick ran %d tiles, want %d", tc.threads, len(tileTimes), tc.cols*tc.rows)
+		}
+		if got := runtime.GOMAXPROCS(0); got != procs {
+			t.Errorf("SetThreads(%d): GOMAXPROCS changed from %d to %d", tc.threads, procs, got)
+		}
+	}
+
+	// more threads than columns run one per column
+	if err := SetThreads(100); err != nil {
+		t.Fatal(err)
+	}
+	if WorkerThreads() != 40 {
+		t.Errorf("SetThreads(100) on a 40-wide grid: %d workers, want 40", WorkerThreads())
+	}
+}
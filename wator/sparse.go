@@ -0,0 +1,166 @@
+package wator
+
+/// @file sparse.go
+/// @brief Optional worklist of occupied cells for low-density worlds.
+/// @details With `-sparse`, `Update()` visits only the cells listed in the
+/// per-tile worklists instead of scanning every cell. The next tick's cells
+/// are collected as creatures are written into `buffer` (each buffer cell
+/// is occupied at most once per tick, under its claim, see claim.go), in a
+/// list of the tile whose creature wrote them, so no two workers append to
+/// the same list. After the swap they are sorted into the lists of the
+/// tiles they lie in, which become the current lists. Each list is sorted,
+/// so cells are visited in the same row-major order as the dense scan and
+/// results are unchanged. Anything that edits `Grid` outside `Update()`
+/// must call `InvalidateWorklist()`, which makes the next tick rebuild the
+/// lists with one full scan. The dense scan stays the default: it wins
+/// once the grid is crowded, because it needs no list building or sorting.
+///
+/// `-engine auto` picks one of the two before every tick from the share of
+/// occupied cells after the last one, which the tiles count as they fill
+/// the buffer: it switches to the worklists below `autoSparseOn` and back
+/// to the scan above `autoSparseOff`, so a population hovering around the
+/// crossover (about 5% on 400x400) does not flip every tick. With
+/// `-tile-order random` the two visit different shuffles, so auto keeps
+/// the scan and its results are always those of `dense`.
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	EngineDense  = "dense"
+	EngineSparse = "sparse"
+	EngineAuto   = "auto"
+)
+
+// / @brief How `Update()` picks between scan and worklists, set with `-engine`.
+var Engine string = EngineDense
+
+// / @brief Visit only occupied cells in `Update()`; with `EngineAuto`,
+// / `Update()` sets it itself before every tick.
+var Sparse bool = false
+
+// / @brief Occupied shares at which `-engine auto` switches to the
+// / worklists and back to the scan.
+const autoSparseOn = 0.04
+const autoSparseOff = 0.06
+
+var occupied int = -1 // occupied cells after the last tick, -1 if unknown
+var tileFilled []int  // cells each tile occupied in `buffer` this tick
+
+var work [][]int32     // per-tile cells (y*width+x) to visit this tick
+var nextWork [][]int32 // cells filled in during this tick, by writing tile
+var workLayout [4]int  // cols, rows, tileW, tileH the lists were built for
+var workValid bool = false
+
+// / @brief Force the worklists to be rebuilt from `Grid` on the next tick.
+// / @details Called wherever the world changes outside `Update()`, so it
+// / also drops the rolling fingerprint (fingerprint.go).
+func InvalidateWorklist() {
+	workValid = false
+	rollingValid = false
+	occupied = -1
+}
+
+// / @brief Validate and select an engine.
+// / @param name One of "dense", "sparse" or "auto".
+// / @return error Non-nil for an unknown engine.
+func SetEngine(name string) error {
+	switch name {
+	case EngineDense, EngineSparse, EngineAuto:
+		Engine = name
+		Sparse = name == EngineSparse
+		return nil
+	}
+	return fmt.Errorf("unknown engine %q (want %s, %s or %s)", name, EngineDense, EngineSparse, EngineAuto)
+}
+
+// / @brief Whether `-engine auto` should use the worklists for the coming
+// / tick; keeps the current choice while the occupancy is unknown.
+func autoSparse() bool {
+	if tileOrder == OrderRandom {
+		return false
+	}
+	if occupied < 0 {
+		return Sparse
+	}
+	share := float64(occupied) / float64(Width*Height)
+	if Sparse {
+		return share < autoSparseOff
+	}
+	return share < autoSparseOn
+}
+
+// / @brief Zero the per-tile fill counters before a tick.
+// / @param n Number of tiles.
+func resetFilled(n int) {
+	if len(tileFilled) != n {
+		tileFilled = make([]int, n)
+	}
+	clear(tileFilled)
+}
+
+// / @brief Total the fill counters once the tiles are done.
+func finishFilled() {
+	occupied = 0
+	for _, n := range tileFilled {
+		occupied += n
+	}
+}
+
+// / @brief Make `work` match `Grid` and empty `nextWork` before a tick.
+// / @param cols, rows, tileW, tileH The tile layout of this tick.
+func prepareWorklist(cols, rows, tileW, tileH int) {
+	layout := [4]int{cols, rows, tileW, tileH}
+	if len(nextWork) != cols*rows {
+		nextWork = make([][]int32, cols*rows)
+	}
+	for i := range nextWork {
+		nextWork[i] = nextWork[i][:0]
+	}
+
+	if workValid && layout == workLayout {
+		return
+	}
+	if len(work) != cols*rows {
+		work = make([][]int32, cols*rows)
+	}
+	for i := range work {
+		work[i] = work[i][:0]
+	}
+	// scan in index order, so every list comes out sorted
+	for i, s := range Grid {
+		if s != 0 {
+			id := (i%Width/tileW)*rows + i/Width/tileH
+			work[id] = append(work[id], int32(i))
+		}
+	}
+	workLayout = layout
+	workValid = true
+}
+
+// / @brief Build the next lists from the cells filled during the tick;
+// / call after the swap.
+func finishWorklist() {
+	rows, tileW, tileH := workLayout[1], workLayout[2], workLayout[3]
+	for i := range work {
+		work[i] = work[i][:0]
+	}
+	for _, list := range nextWork {
+		for _, c := range list {
+			id := (int(c)%Width/tileW)*rows + int(c)/Width/tileH
+			work[id] = append(work[id], c)
+		}
+	}
+	for _, list := range work {
+		sort.Sort(cellList(list))
+	}
+}
+
+// / @brief sort.Interface for a worklist.
+type cellList []int32
+
+func (c cellList) Len() int           { return len(c) }
+func (c cellList) Less(i, j int) bool { return c[i] < c[j] }
+func (c cellList) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
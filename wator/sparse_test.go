@@ -0,0 +1,104 @@
+package wator
+
+import (
+	"fmt"
+	"testing"
+)
+
+// / @brief The world after each of `ticks` ticks of a low-density world,
+// / built with `cfg` run after loading, and the number of ticks that used
+// / the worklists.
+func sparseRun(t *testing.T, threads, ticks int, cfg func()) ([]string, int) {
+	t.Helper()
+	w, err := NewWorld(WithSize(100, 80), WithFish(200), WithSharks(60), WithThreads(threads), WithSeed(11))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Load()
+	defer w.Unload()
+	cfg()
+	InitWorld()
+	states := make([]string, ticks)
+	listed := 0
+	for i := range states {
+		if err := Update(); err != nil {
+			t.Fatal(err)
+		}
+		if Sparse {
+			listed++
+		}
+		states[i] = worldState()
+	}
+	return states, listed
+}
+
+// / @brief The worklists, alone and picked by `-engine auto`, give the
+// / world of the serial dense scan tick by tick.
+// / @details With more threads the runs use `phased`, the scheduler whose
+// / parallel ticks repeat the serial ones (see sched.go).
+func TestSparseMatchesSerial(t *testing.T) {
+	const ticks = 80
+	for _, tc := range []struct {
+		sched   string
+		threads int
+	}{{SchedStatic, 1}, {SchedPhased, 4}} {
+		want, _ := sparseRun(t, tc.threads, ticks, func() {
+			SetScheduler(tc.sched)
+			SerialTiles = true
+		})
+		for _, engine := range []string{EngineSparse, EngineAuto} {
+			t.Run(fmt.Sprintf("%s/%s/threads=%d", engine, tc.sched, tc.threads), func(t *testing.T) {
+				got, listed := sparseRun(t, tc.threads, ticks, func() {
+					SetScheduler(tc.sched)
+					if err := SetEngine(engine); err != nil {
+						t.Fatal(err)
+					}
+				})
+				if listed == 0 {
+					t.Fatal("no tick used the worklists")
+				}
+				for i := range got {
+					if got[i] != want[i] {
+						t.Fatalf("tick %d differs from the serial scan", i+1)
+					}
+				}
+			})
+		}
+	}
+}
+
+// / @brief Ticks of a 400x400 world at 1% and 5% occupancy, scanned and
+// / with the worklists. Fish do not breed and the world is rebuilt every 20
+// / ticks, so the occupancy never rises above its start.
+func BenchmarkSparse(b *testing.B) {
+	for _, pct := range []int{1, 5} {
+		for _, engine := range []string{EngineDense, EngineSparse} {
+			b.Run(fmt.Sprintf("occupied=%d%%/%s", pct, engine), func(b *testing.B) {
+				cells := 400 * 400 * pct / 100
+				w, err := NewWorld(WithSize(400, 400), WithFish(cells*2/3), WithSharks(cells/3),
+					WithThreads(1), WithSeed(1))
+				if err != nil {
+					b.Fatal(err)
+				}
+				w.Load()
+				defer w.Unload()
+				if err := SetEngine(engine); err != nil {
+					b.Fatal(err)
+				}
+				NoFishBreed = true
+				InitWorld()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if Tick == 20 {
+						b.StopTimer()
+						InitWorld()
+						b.StartTimer()
+					}
+					if err := Update(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
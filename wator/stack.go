@@ -0,0 +1,30 @@
+package wator
+
+/// @file stack.go
+/// @brief Schools of fish: several fish sharing one cell.
+/// @details With `-stacking` a fish cell holds a school of up to
+/// `MaxStack` fish (`StackCount`), which moves and ages as one creature
+/// with one set of timers. When its breed timer expires a school below the
+/// cap grows by one instead of leaving a newborn behind; a full school
+/// breeds as usual, leaving a school of one. A shark next to a school eats
+/// a single fish of it and stays where it is, since the cell is still
+/// occupied; only the last fish is eaten by moving in. When a shark and a
+/// moving school meet in a contested cell (see `resolveConflict`) the whole
+/// school is lost.
+///
+/// Without `-stacking` every fish cell holds exactly one fish and the
+/// rules are unchanged.
+
+// / @brief Allow several fish per cell.
+var Stacking bool = false
+
+// / @brief Largest school in `-stacking` mode.
+var MaxStack int = 4
+
+// / @brief Number of fish in the fish cell with index `i`.
+func fishIn(i int) int {
+	if Stacking {
+		return int(StackCount[i])
+	}
+	return 1
+}
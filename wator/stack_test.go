@@ -0,0 +1,53 @@
+package wator
+
+import "testing"
+
+// / @brief A school that cannot move grows by one fish each time it breeds,
+// / up to `MaxStack`, and stays at the cap.
+func TestSchoolGrowsToCap(t *testing.T) {
+	const maxStack = 3
+	// a single cell is its own neighbor on every side
+	w := newTestWorld(t, "f", func() { Stacking, MaxStack, FishBreed = true, maxStack, 1 })
+	prev := 1
+	for tick := 1; tick <= 3*maxStack; tick++ {
+		step(t, w, 1)
+		n := w.Fish()
+		if n < prev || n > prev+1 {
+			t.Fatalf("tick %d: school went from %d to %d fish, want growth by at most one", tick, prev, n)
+		}
+		if n > maxStack {
+			t.Fatalf("tick %d: school of %d beyond the cap of %d", tick, n, maxStack)
+		}
+		prev = n
+	}
+	if prev != maxStack {
+		t.Errorf("school stopped at %d fish, want the cap of %d", prev, maxStack)
+	}
+	if c := w.Cell(0, 0); c.School != maxStack {
+		t.Errorf("cell reports a school of %d, want %d", c.School, maxStack)
+	}
+}
+
+// / @brief A shark next to a school eats one fish a tick and keeps its own
+// / cell while fish are left; it moves in only for the last one.
+func TestSharkThinsSchool(t *testing.T) {
+	// the school's only way out is the shark's cell, so it stays
+	w := newTestWorld(t, "Sf", func() {
+		Stacking, NoFishBreed, SharkStarve, Deterministic = true, true, 9, true
+	})
+	StackCount[Index(1, 0)] = 3
+
+	for left := 2; left >= 1; left-- {
+		step(t, w, 1)
+		if c := w.Cell(1, 0); c.State != 1 || c.School != left {
+			t.Fatalf("tick %d: school cell holds %+v, want a school of %d", Tick, c, left)
+		}
+		if c := w.Cell(0, 0); c.State != 2 || c.Starve != SharkStarve {
+			t.Fatalf("tick %d: shark cell holds %+v, want a fed shark that stayed", Tick, c)
+		}
+	}
+	step(t, w, 1)
+	if f, c := w.Fish(), w.Cell(1, 0); f != 0 || c.State != 2 {
+		t.Errorf("after the last fish: %d fish, school cell holds %+v, want the shark", f, c)
+	}
+}
@@ -0,0 +1,480 @@
+package wator
+
+/// @file state.go
+/// @brief Versioned binary file format for the full world state.
+/// @details Unlike the RLE snapshot (rle.go), a state file also holds the
+/// timers, school sizes and tick, so a world can be resumed exactly where
+/// it was saved. Layout (integers little-endian):
+///
+///	offset 0   4 bytes "WATR"
+///	offset 4   uint16  format version (`stateVersion`)
+///	offset 6   uint16  grid width
+///	offset 8   uint16  grid height
+///	offset 10  uint16  feature flags: which sections follow the cells
+///	offset 12  uint64  tick
+///	offset 20  sections: cells, then one per feature flag in the order of
+///	           the flag bits (breed timers, starve timers, school sizes,
+///	           random source)
+///
+/// Every section starts with its length in bytes as an unsigned varint.
+/// Grid sections list values in row-major order (y outer, x inner) as
+/// runs: the value as a signed varint followed by the run length as an
+/// unsigned varint. The cell section covers every cell, so the mostly empty
+/// ocean collapses into a few long runs of 0; the timer sections only cover
+/// the occupied cells and the school section only the fish, in the same
+/// order, since the values of the other cells are always 0. The random source section is the
+/// seed (int64) and the number of values drawn since (uint64), see rng.go.
+/// The file ends right after the last section.
+///
+/// Flags 1-128 are required: a reader rejects the ones it does not know.
+/// Flags 256 and up mark optional sections that a reader may skip thanks
+/// to the length prefix, so later versions can add data without breaking
+/// older readers. Version 1 files (no length prefixes, timer sections over
+/// all cells) are still read.
+///
+/// `WriteBinary()` / `ReadBinary()` handle the world alone, for a grid of
+/// the current size. `SaveState()` / `LoadState()` also store and restore
+/// the random source, and loading adopts the saved grid size, so that a run
+/// resumed from the file continues with exactly the ticks it would have
+/// simulated had it never stopped.
+///
+/// Readers reject newer versions, unknown required flags and any malformed
+/// or truncated data with an error, never by panicking. Sections missing
+/// from a file are filled in the way `spawn()` sets up new creatures.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const stateMagic = "WATR"
+const stateVersion = 2
+
+// / @brief Feature flags of the state header.
+const (
+	stateBreed  = 1 << iota // breed timers present
+	stateStarve             // starve timers present
+	stateStack              // school sizes present
+	stateRNG                // random source present
+
+	stateWorld    = stateBreed | stateStarve | stateStack
+	stateAll      = stateWorld | stateRNG
+	stateRequired = 0x00ff // flags a reader must understand
+)
+
+const stateHeaderSize = 20
+
+// / @brief Write the current world to `w` in the binary state format.
+// / @return error Any error from `w`.
+func WriteBinary(w io.Writer) error {
+	return writeState(w, stateWorld)
+}
+
+// / @brief Write the world and the random source to `w`, for resuming the
+// / run later with `LoadState()`.
+// / @return error Any error from `w`.
+func SaveState(w io.Writer) error {
+	return writeState(w, stateAll)
+}
+
+// / @brief Write a state file with the sections of `flags`.
+func writeState(w io.Writer, flags uint16) error {
+	out := make([]byte, stateHeaderSize, 1<<12)
+	copy(out, stateMagic)
+	binary.LittleEndian.PutUint16(out[4:], stateVersion)
+	binary.LittleEndian.PutUint16(out[6:], uint16(Width))
+	binary.LittleEndian.PutUint16(out[8:], uint16(Height))
+	binary.LittleEndian.PutUint16(out[10:], flags)
+	binary.LittleEndian.PutUint64(out[12:], uint64(Tick))
+
+	body := make([]byte, 0, 1<<12)
+	section := func() {
+		out = binary.AppendUvarint(out, uint64(len(body)))
+		out = append(out, body...)
+		body = body[:0]
+	}
+	body = appendRuns(body, anyCell, func(i int) int64 { return int64(Grid[i]) })
+	section()
+	body = appendRuns(body, occupiedCell, func(i int) int64 { return int64(BreedTimer[i]) })
+	section()
+	body = appendRuns(body, occupiedCell, func(i int) int64 { return int64(StarveTimer[i]) })
+	section()
+	body = appendRuns(body, fishCell, func(i int) int64 { return int64(StackCount[i]) })
+	section()
+	if flags&stateRNG != 0 {
+		seed, draws := rngState()
+		body = binary.LittleEndian.AppendUint64(body, uint64(seed))
+		body = binary.LittleEndian.AppendUint64(body, draws)
+		section()
+	}
+
+	_, err := w.Write(out)
+	return err
+}
+
+// / @brief Which cells a grid section covers, by cell state.
+func anyCell(c uint8) bool      { return true }
+func occupiedCell(c uint8) bool { return c != 0 }
+func fishCell(c uint8) bool     { return c == 1 }
+
+// / @brief Append the runs of `value` over the cells selected by `covers`.
+func appendRuns(out []byte, covers func(c uint8) bool, value func(i int) int64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	run := uint64(0)
+	var cur int64
+	flush := func() {
+		out = append(out, tmp[:binary.PutVarint(tmp[:], cur)]...)
+		out = append(out, tmp[:binary.PutUvarint(tmp[:], run)]...)
+	}
+	for i, c := range Grid {
+		if !covers(c) {
+			continue
+		}
+		v := value(i)
+		if run > 0 && v == cur {
+			run++
+			continue
+		}
+		if run > 0 {
+			flush()
+		}
+		cur, run = v, 1
+	}
+	if run > 0 {
+		flush()
+	}
+	return out
+}
+
+// / @brief `n` consecutive cells holding `v`.
+type valueRun struct {
+	n int
+	v int64
+}
+
+// / @brief Decode `cells` values stored as runs from the front of `data`.
+// / @details The values stay runs: `cells` comes from the file's header,
+// / and one run of a few bytes may cover all of them. Every run takes at
+// / least two bytes, so the result never holds more runs than `data` has
+// / byte pairs, whatever grid the header claims.
+// / @param name Section name for error messages.
+// / @param max Largest allowed value; values below 0 are always rejected.
+// / @return []valueRun The runs in row-major order.
+// / @return []byte The data after the runs.
+func readRuns(data []byte, name string, cells int, max int64) ([]valueRun, []byte, error) {
+	if cells > 0 && len(data) < 2 {
+		return nil, nil, fmt.Errorf("state: %s: truncated value", name)
+	}
+	runs := make([]valueRun, 0, min(cells, len(data)/2))
+	for left := cells; left > 0; {
+		v, k := binary.Varint(data)
+		if k <= 0 {
+			return nil, nil, fmt.Errorf("state: %s: truncated value", name)
+		}
+		n, k2 := binary.Uvarint(data[k:])
+		if k2 <= 0 {
+			return nil, nil, fmt.Errorf("state: %s: truncated run length", name)
+		}
+		if v < 0 || v > max {
+			return nil, nil, fmt.Errorf("state: %s: invalid value %d", name, v)
+		}
+		if n == 0 || n > uint64(left) {
+			return nil, nil, fmt.Errorf("state: %s: run of %d cells does not fit the grid", name, n)
+		}
+		runs = append(runs, valueRun{int(n), v})
+		left -= int(n)
+		data = data[k+k2:]
+	}
+	return runs, data, nil
+}
+
+// / @brief Reads the values of decoded runs one cell at a time.
+type runCursor struct {
+	runs []valueRun
+	used int // values taken from runs[0]
+}
+
+// / @brief The next value; the runs must not be used up.
+func (c *runCursor) next() int64 {
+	r := c.runs[0]
+	if c.used++; c.used == r.n {
+		c.runs, c.used = c.runs[1:], 0
+	}
+	return r.v
+}
+
+// / @brief A decoded state file.
+type savedState struct {
+	width, height               int
+	flags                       uint16
+	tick                        int
+	cells, breed, starve, stack []valueRun // nil if the section is missing
+	allCells                    bool       // timer and school sections cover every cell (version 1)
+	seed                        int64
+	draws                       uint64
+}
+
+// / @brief Replace the world with a state read from `r`.
+// / @details The whole file is validated before anything is changed. A
+// / random source stored in the file is ignored.
+// / @return error Non-nil for a read error, a version or size mismatch,
+// / unknown features or malformed data.
+func ReadBinary(r io.Reader) error {
+	st, err := readState(r, func(w, h int) error {
+		if w != Width || h != Height {
+			return fmt.Errorf("state: file is %dx%d, grid is %dx%d", w, h, Width, Height)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	st.apply()
+	return nil
+}
+
+// / @brief Resume a run saved with `SaveState()`.
+// / @details The whole file is validated before anything is changed. The
+// / grid takes the size of the file (see `SetSize()`) and the random source
+// / continues where it was saved; a file without a random source, such as
+// / one from `WriteBinary()`, leaves the current source as it is. A pcg
+// / source jumps straight to the saved position; the `math/rand` one
+// / replays every value drawn before the save, so a file with more draws
+// / than it can replay in a few seconds is rejected (see rng.go).
+// / @return error Non-nil for a read error, an unusable size, unknown
+// / features, malformed data or too many draws to replay.
+func LoadState(r io.Reader) error {
+	st, err := readState(r, func(w, h int) error {
+		if err := checkSize(w, h); err != nil {
+			return fmt.Errorf("state: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if st.flags&stateRNG != 0 {
+		if err := canRestore(st.draws); err != nil {
+			return fmt.Errorf("state: %v", err)
+		}
+	}
+	if st.width != Width || st.height != Height {
+		if err := SetSize(st.width, st.height); err != nil {
+			return fmt.Errorf("state: %v", err)
+		}
+	}
+	st.apply()
+	if st.flags&stateRNG != 0 {
+		source.restore(st.seed, st.draws)
+	}
+	return nil
+}
+
+// / @brief Decode and validate a state file.
+// / @param size Checks the grid size of the header before any section is
+// / decoded.
+func readState(r io.Reader, size func(w, h int) error) (*savedState, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < stateHeaderSize {
+		return nil, errors.New("state: file too short for the header")
+	}
+	if !bytes.Equal(data[:4], []byte(stateMagic)) {
+		return nil, errors.New("state: not a Wa-Tor state file")
+	}
+	version := binary.LittleEndian.Uint16(data[4:])
+	if version < 1 || version > stateVersion {
+		return nil, fmt.Errorf("state: file has format version %d, this build reads versions 1 to %d", version, stateVersion)
+	}
+	st := &savedState{
+		width:  int(binary.LittleEndian.Uint16(data[6:])),
+		height: int(binary.LittleEndian.Uint16(data[8:])),
+		flags:  binary.LittleEndian.Uint16(data[10:]),
+	}
+	if st.width == 0 || st.height == 0 {
+		return nil, fmt.Errorf("state: invalid grid size %dx%d", st.width, st.height)
+	}
+	if err := size(st.width, st.height); err != nil {
+		return nil, err
+	}
+	unknown := st.flags &^ stateAll
+	if version > 1 {
+		unknown &= stateRequired
+	}
+	if unknown != 0 {
+		return nil, fmt.Errorf("state: unsupported feature flags %#x", unknown)
+	}
+	t := binary.LittleEndian.Uint64(data[12:])
+	if t > 1<<62 {
+		return nil, fmt.Errorf("state: invalid tick %d", t)
+	}
+	st.tick = int(t)
+
+	rest := data[stateHeaderSize:]
+	st.allCells = version == 1
+	if version == 1 {
+		err = st.readV1(rest)
+	} else {
+		err = st.readSections(rest)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+const maxTimer = 1 << 40
+
+// / @brief Decode the sections of a version 1 file.
+func (st *savedState) readV1(rest []byte) error {
+	n := st.width * st.height
+	var err error
+	if st.cells, rest, err = readRuns(rest, "cells", n, 2); err != nil {
+		return err
+	}
+	if st.flags&stateBreed != 0 {
+		if st.breed, rest, err = readRuns(rest, "breed timers", n, maxTimer); err != nil {
+			return err
+		}
+	}
+	if st.flags&stateStarve != 0 {
+		if st.starve, rest, err = readRuns(rest, "starve timers", n, maxTimer); err != nil {
+			return err
+		}
+	}
+	if st.flags&stateStack != 0 {
+		if st.stack, rest, err = readRuns(rest, "school sizes", n, 255); err != nil {
+			return err
+		}
+	}
+	if st.flags&stateRNG != 0 {
+		if len(rest) < 16 {
+			return errors.New("state: random source: truncated")
+		}
+		st.seed = int64(binary.LittleEndian.Uint64(rest))
+		st.draws = binary.LittleEndian.Uint64(rest[8:])
+		rest = rest[16:]
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("state: %d unexpected bytes after the last section", len(rest))
+	}
+	return nil
+}
+
+// / @brief Decode the length-prefixed sections of a current file.
+func (st *savedState) readSections(rest []byte) error {
+	next := func(name string) ([]byte, error) {
+		size, k := binary.Uvarint(rest)
+		if k <= 0 || size > uint64(len(rest)-k) {
+			return nil, fmt.Errorf("state: %s: truncated section", name)
+		}
+		body := rest[k : k+int(size)]
+		rest = rest[k+int(size):]
+		return body, nil
+	}
+	// runs decodes a whole grid section of `count` values.
+	runs := func(name string, count int, max int64) ([]valueRun, error) {
+		body, err := next(name)
+		if err != nil {
+			return nil, err
+		}
+		vals, tail, err := readRuns(body, name, count, max)
+		if err == nil && len(tail) != 0 {
+			err = fmt.Errorf("state: %s: %d unexpected bytes at the end of the section", name, len(tail))
+		}
+		return vals, err
+	}
+
+	var err error
+	if st.cells, err = runs("cells", st.width*st.height, 2); err != nil {
+		return err
+	}
+	count := func(covers func(c uint8) bool) int {
+		n := 0
+		for _, r := range st.cells {
+			if covers(uint8(r.v)) {
+				n += r.n
+			}
+		}
+		return n
+	}
+	occupied, fish := count(occupiedCell), count(fishCell)
+	for bit := uint16(1); bit != 0; bit <<= 1 {
+		if st.flags&bit == 0 {
+			continue
+		}
+		switch bit {
+		case stateBreed:
+			st.breed, err = runs("breed timers", occupied, maxTimer)
+		case stateStarve:
+			st.starve, err = runs("starve timers", occupied, maxTimer)
+		case stateStack:
+			st.stack, err = runs("school sizes", fish, 255)
+		case stateRNG:
+			var body []byte
+			if body, err = next("random source"); err == nil && len(body) != 16 {
+				err = fmt.Errorf("state: random source: section has %d bytes, want 16", len(body))
+			}
+			if err == nil {
+				st.seed = int64(binary.LittleEndian.Uint64(body))
+				st.draws = binary.LittleEndian.Uint64(body[8:])
+			}
+		default:
+			// optional section of a newer version
+			_, err = next(fmt.Sprintf("section %#x", bit))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("state: %d unexpected bytes after the last section", len(rest))
+	}
+	return nil
+}
+
+// / @brief Install the decoded world; the grid must already have its size.
+// / @details Runs are read cell by cell as the grid is filled. The timer
+// / sections of a current file skip the empty cells and the school section
+// / all but the fish; version 1 sections cover every cell.
+func (st *savedState) apply() {
+	InvalidateWorklist()
+	clear(Grid)
+	clear(BreedTimer)
+	clear(StarveTimer)
+	clear(StackCount)
+	cells := runCursor{runs: st.cells}
+	breed, starve, stack := runCursor{runs: st.breed}, runCursor{runs: st.starve}, runCursor{runs: st.stack}
+	for i := range Grid {
+		c := uint8(cells.next())
+		var b, s, k int64
+		if st.breed != nil && (st.allCells || occupiedCell(c)) {
+			b = breed.next()
+		}
+		if st.starve != nil && (st.allCells || occupiedCell(c)) {
+			s = starve.next()
+		}
+		if st.stack != nil && (st.allCells || fishCell(c)) {
+			k = stack.next()
+		}
+		if c == 0 {
+			continue
+		}
+		spawn(i%Width, i/Width, c)
+		if st.breed != nil {
+			BreedTimer[i] = int(b)
+		}
+		if st.starve != nil {
+			StarveTimer[i] = int(s)
+		}
+		if st.stack != nil && c == 1 && k > 0 {
+			StackCount[i] = uint8(k)
+		}
+	}
+	Tick = st.tick
+}
@@ -0,0 +1,233 @@
+package wator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// / @brief A version 2 header for a `w` x `h` grid, followed by `body`.
+func stateFile(w, h int, flags uint16, body []byte) []byte {
+	out := []byte(stateMagic)
+	out = binary.LittleEndian.AppendUint16(out, stateVersion)
+	out = binary.LittleEndian.AppendUint16(out, uint16(w))
+	out = binary.LittleEndian.AppendUint16(out, uint16(h))
+	out = binary.LittleEndian.AppendUint16(out, flags)
+	out = binary.LittleEndian.AppendUint64(out, 0)
+	return append(out, body...)
+}
+
+// / @brief A header claiming the largest grid over a few bytes of cells
+// / must be rejected without allocating for the claimed size.
+func TestReadStateHugeHeader(t *testing.T) {
+	// one run of 5 empty cells, then nothing
+	cells := []byte{0, 5}
+	data := stateFile(maxSide, maxSide, 0, append([]byte{byte(len(cells))}, cells...))
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := readState(bytes.NewReader(data), checkSize)
+	runtime.ReadMemStats(&after)
+	if err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Fatalf("got error %v, want a truncated section", err)
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Errorf("reading the file allocated %d bytes", n)
+	}
+}
+
+// / @brief A tiny file whose header claims a huge grid and whose one run
+// / covers all of it fails fast, without allocating for the claimed cells.
+func TestReadStateHugeRun(t *testing.T) {
+	huge := func(side int) []byte {
+		cells := binary.AppendUvarint([]byte{0}, uint64(side*side))
+		// the stray byte after the last section fails the otherwise valid file
+		return append(stateFile(side, side, 0, append([]byte{byte(len(cells))}, cells...)), 0)
+	}
+	newTestWorld(t, "f.\n.S", nil)
+	before := worldState()
+	for _, tc := range []struct {
+		name string
+		read func() error
+		want string
+	}{
+		{"ReadBinary, 8000x8000", func() error { return ReadBinary(bytes.NewReader(huge(8000))) }, "grid is 2x2"},
+		{"LoadState, largest grid", func() error { return LoadState(bytes.NewReader(huge(maxSide))) }, "unexpected bytes"},
+	} {
+		var m0, m1 runtime.MemStats
+		runtime.ReadMemStats(&m0)
+		err := tc.read()
+		runtime.ReadMemStats(&m1)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got error %v, want one containing %q", tc.name, err, tc.want)
+		}
+		if n := m1.TotalAlloc - m0.TotalAlloc; n > 1<<20 {
+			t.Errorf("%s: reading the file allocated %d bytes", tc.name, n)
+		}
+		if got := worldState(); got != before {
+			t.Errorf("%s: the rejected file changed the world to\n%s", tc.name, got)
+		}
+	}
+}
+
+// / @brief Skipping ahead lands where stepping one value at a time does.
+func TestPCGAdvance(t *testing.T) {
+	for _, n := range []uint64{0, 1, 2, 3, 1000, 12345} {
+		a, b := newPCG(42, masterStream), newPCG(42, masterStream)
+		for i := uint64(0); i < n; i++ {
+			a.Uint32()
+		}
+		b.advance(n)
+		if *a != *b {
+			t.Errorf("advance(%d) = %+v, stepping gives %+v", n, *b, *a)
+		}
+	}
+}
+
+// / @brief A state claiming more draws than a `math/rand` source can replay
+// / is rejected at once; a pcg source restores it and continues the run.
+func TestLoadStateManyDraws(t *testing.T) {
+	w, err := NewWorld(WithSize(20, 20), WithFish(40), WithSharks(10), WithSeed(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Load()
+	defer w.Unload()
+	defer SetRNG(RNGMath)
+
+	source.draws = 1 << 40
+	var buf bytes.Buffer
+	if err := SaveState(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadState(bytes.NewReader(buf.Bytes())); err == nil || !strings.Contains(err.Error(), "draws") {
+		t.Fatalf("math source: got error %v, want too many draws", err)
+	}
+
+	if err := SetRNG(RNGPCG); err != nil {
+		t.Fatal(err)
+	}
+	Seed(3)
+	p := *source.src.(*pcgSource).p
+	p.advance(2 << 40)
+	source.draws = 1 << 40
+	buf.Reset()
+	if err := SaveState(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadState(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if got := *source.src.(*pcgSource).p; got != p {
+		t.Errorf("pcg source restored to %+v, want %+v", got, p)
+	}
+	if _, draws := rngState(); draws != 1<<40 {
+		t.Errorf("restored %d draws, want %d", draws, uint64(1<<40))
+	}
+}
+
+// / @brief A saved run resumes exactly: the loaded world equals the saved
+// / one, and both continue with the same ticks.
+func TestStateRoundTrip(t *testing.T) {
+	// one thread: ticks of the parallel static scheduler do not repeat
+	w, err := NewWorld(WithSize(30, 20), WithFish(120), WithSharks(30), WithFishStarve(6), WithSeed(5),
+		WithThreads(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Do(func() { Stacking = true })
+	step(t, w, 20)
+	var saved bytes.Buffer
+	var want, wantLater string
+	w.Do(func() {
+		if err := SaveState(&saved); err != nil {
+			t.Fatal(err)
+		}
+		want = worldState()
+	})
+	step(t, w, 10)
+	w.Do(func() { wantLater = worldState() })
+
+	// a world of another size and seed adopts everything from the file
+	r, err := NewWorld(WithSize(8, 8), WithFish(4), WithSharks(1), WithFishStarve(6), WithSeed(99),
+		WithThreads(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Do(func() {
+		Stacking = true
+		if err := LoadState(bytes.NewReader(saved.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+		if got := worldState(); got != want {
+			t.Errorf("loaded world differs from the saved one:\n%s\nwant\n%s", got, want)
+		}
+	})
+	if r.Tick() != 20 {
+		t.Errorf("loaded tick %d, want 20", r.Tick())
+	}
+	step(t, r, 10)
+	r.Do(func() {
+		if got := worldState(); got != wantLater {
+			t.Errorf("resumed run differs after 10 ticks:\n%s\nwant\n%s", got, wantLater)
+		}
+	})
+}
+
+// / @brief Truncated and corrupted files are rejected with an error, never
+// / a panic, and leave the world as it was.
+func TestLoadStateDamaged(t *testing.T) {
+	// a pcg source restores any number of draws at once, whatever a flipped
+	// byte makes of the count
+	newTestWorld(t, `
+f..S
+.f..
+..S.`, func() { SetRNG(RNGPCG) })
+	var buf bytes.Buffer
+	if err := SaveState(&buf); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+	before := worldState()
+	fail := func(name string, data []byte) {
+		t.Helper()
+		if err := LoadState(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+		if got := worldState(); got != before {
+			t.Errorf("%s: the rejected file changed the world to\n%s", name, got)
+		}
+	}
+
+	for n := 0; n < len(good); n++ {
+		fail(fmt.Sprintf("truncated to %d bytes", n), good[:n])
+	}
+	corrupt := func(off int, b ...byte) []byte {
+		data := bytes.Clone(good)
+		copy(data[off:], b)
+		return data
+	}
+	fail("bad magic", corrupt(0, 'X'))
+	fail("newer version", corrupt(4, stateVersion+1))
+	fail("width 0", corrupt(6, 0, 0))
+	fail("unknown required flag", corrupt(10, byte(good[10]|0x80)))
+	fail("trailing data", append(bytes.Clone(good), 0))
+	fail("cell state 3", corrupt(stateHeaderSize+1, 6)) // the first run's value, zigzag 3
+
+	// flipping any one byte may give another valid file, but must not panic
+	for off := range good {
+		for _, bit := range []byte{1, 0x40, 0x80} {
+			data := corrupt(off, good[off]^bit)
+			if LoadState(bytes.NewReader(data)) != nil && worldState() != before {
+				t.Errorf("byte %d ^ %#x: rejected file changed the world", off, bit)
+			}
+			// undo whatever a file that was accepted loaded
+			if err := LoadState(bytes.NewReader(good)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package wator
+
+/// @file summary.go
+/// @brief End-of-run image of where life concentrated.
+/// @details With `-summary-png out.png` every cell counts the ticks it was
+/// occupied by a fish or shark. The counters are bumped by the tile
+/// goroutines in `Update()` as they fill the next-state buffer, under the
+/// cell claim they already hold, so accumulating costs no extra pass. At the
+/// end of the run the counts are normalized to the busiest cell and drawn
+/// with a black-red-yellow-white ramp, one pixel per cell.
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+)
+
+// / @brief Ticks each cell was occupied; nil unless `-summary-png` is set.
+var occupancy []uint32
+
+// / @brief Start counting occupancy from now on.
+func EnableSummary() {
+	occupancy = NewPlane[uint32]()
+}
+
+// / @brief Color of a normalized count on the heat ramp.
+// / @param t Value in [0, 1].
+func heatColor(t float64) color.RGBA {
+	v := t * 3
+	switch {
+	case v < 1:
+		return color.RGBA{uint8(255 * v), 0, 0, 255}
+	case v < 2:
+		return color.RGBA{255, uint8(255 * (v - 1)), 0, 255}
+	default:
+		return color.RGBA{255, 255, uint8(255 * (v - 2)), 255}
+	}
+}
+
+// / @brief Render the normalized occupancy counts.
+func summaryImage() *image.RGBA {
+	var max uint32
+	for _, n := range occupancy {
+		if n > max {
+			max = n
+		}
+	}
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	for i, n := range occupancy {
+		t := 0.0
+		if max > 0 {
+			t = float64(n) / float64(max)
+		}
+		img.SetRGBA(i%Width, i/Width, heatColor(t))
+	}
+	return img
+}
+
+// / @brief Write the summary image to `path`.
+// / @return error Any error creating or encoding the file.
+func WriteSummaryPNG(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, summaryImage()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,80 @@
+package wator
+
+import (
+	"image/color"
+	"testing"
+)
+
+// / @brief Each cell counts the ticks it ended occupied: a lone fish cycling
+// / round a column visits every cell in turn, a full ocean counts every
+// / tick everywhere, and in a random world the counts add up to the
+// / populations after each tick and draw brighter the higher they are.
+func TestSummaryCounts(t *testing.T) {
+	t.Run("column", func(t *testing.T) {
+		// one cell wide, so the fish's only way out is north
+		w := newTestWorld(t, "f\n.\n.\n.", func() { Deterministic, NoFishBreed = true, true })
+		EnableSummary()
+		step(t, w, 8)
+		for y, n := range occupancy {
+			if n != 2 {
+				t.Errorf("cell (0,%d) counted %d ticks, want 2 of 8 on a column of 4", y, n)
+			}
+		}
+	})
+
+	t.Run("full ocean", func(t *testing.T) {
+		w := newTestWorld(t, "fff\nfff\nfff", nil)
+		EnableSummary()
+		step(t, w, 5)
+		for i, n := range occupancy {
+			if n != 5 {
+				t.Errorf("cell (%d,%d) counted %d ticks, want 5", i%Width, i/Width, n)
+			}
+		}
+	})
+
+	t.Run("random", func(t *testing.T) {
+		w, err := NewWorld(WithSize(20, 16), WithFish(40), WithSharks(10), WithThreads(3), WithSeed(8))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Load()
+		defer w.Unload()
+		EnableSummary()
+		want := 0
+		for i := 0; i < 30; i++ {
+			if err := Update(); err != nil {
+				t.Fatal(err)
+			}
+			want += CountFish() + CountSharks()
+		}
+		got, busiest := 0, 0
+		for i, n := range occupancy {
+			got += int(n)
+			if n > occupancy[busiest] {
+				busiest = i
+			}
+		}
+		if got != want {
+			t.Errorf("counts sum to %d, populations after each tick to %d", got, want)
+		}
+
+		img := summaryImage()
+		if c := img.RGBAAt(busiest%Width, busiest/Width); c != (color.RGBA{255, 255, 255, 255}) {
+			t.Errorf("busiest cell drawn as %v, want white", c)
+		}
+		// the ramp only brightens with the count
+		shade := map[uint32]int{}
+		for i, n := range occupancy {
+			c := img.RGBAAt(i%Width, i/Width)
+			shade[n] = int(c.R) + int(c.G) + int(c.B)
+		}
+		for n, v := range shade {
+			for m, u := range shade {
+				if m < n && u > v {
+					t.Errorf("count %d drawn brighter (%d) than count %d (%d)", m, u, n, v)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package wator
+
+/// @file text.go
+/// @brief Plain text form of the grid, one character per cell.
+/// @details `WriteText()` writes one line per row, top to bottom, with `.`
+/// for water, `f` for a fish and `S` for a shark:
+///
+///	..f.
+///	.fS.
+///	....
+///
+/// Small worlds can so be pasted into bug reports or kept as golden files
+/// and compared with a plain diff. The form holds no timers, school sizes
+/// or tick; `ReadText()` reads it back like `BuildWorld()` does, with the
+/// timers of newly placed creatures, at tick 0. Unlike `-render ascii`
+/// nothing is downsampled, so a default 400x400 grid takes 400 lines of
+/// 400 characters.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// / @brief Character of each cell state in the text form.
+const textChars = ".fS"
+
+// / @brief Write the current grid to `w` in the text form.
+// / @return error Any error from `w`.
+func WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	line := make([]byte, Width+1)
+	line[Width] = '\n'
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			line[x] = textChars[Grid[Index(x, y)]]
+		}
+		bw.Write(line)
+	}
+	return bw.Flush()
+}
+
+// / @brief Replace the world with a grid in the text form read from `r`.
+// / @details The grid takes the size of the text: as many columns as the
+// / lines are long and as many rows as there are lines. Trailing blank
+// / lines and carriage returns are ignored.
+// / @return error Non-nil for a read error, lines of different lengths,
+// / unknown characters or an unusable size; the world is left unchanged.
+func ReadText(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(string(data), "\r", ""), "\n"), "\n")
+	cells := make([][]uint8, len(lines))
+	for y, l := range lines {
+		if len(l) != len(lines[0]) {
+			return fmt.Errorf("text grid: line %d has %d cells, line 1 has %d", y+1, len(l), len(lines[0]))
+		}
+		cells[y] = make([]uint8, len(l))
+		for x := 0; x < len(l); x++ {
+			s := strings.IndexByte(textChars, l[x])
+			if s < 0 {
+				return fmt.Errorf("text grid: line %d, column %d: unknown cell %q (want . f or S)", y+1, x+1, l[x])
+			}
+			cells[y][x] = uint8(s)
+		}
+	}
+	w, h := len(lines[0]), len(lines)
+	if err := checkSize(w, h); err != nil {
+		return fmt.Errorf("text grid: %v", err)
+	}
+	if w != Width || h != Height {
+		if err := SetSize(w, h); err != nil {
+			return fmt.Errorf("text grid: %v", err)
+		}
+	}
+	return BuildWorld(cells)
+}
@@ -0,0 +1,53 @@
+package wator
+
+import (
+	"strings"
+	"testing"
+)
+
+// / @brief `String()` and `WriteText()` of a seeded world match the golden
+// / grids, and the text reads back to the same grid.
+func TestTextGolden(t *testing.T) {
+	golden := []string{`
+f.f......S.fffS.
+...f...f.......f
+f.......f.f...S.
+.....S.ffff...ff
+...f.f.f.ff.f...
+.............SSf
+`, `
+...f...fS.ff.f.f
+.ff......f.f....
+.......f.fff.Sf.
+....f.ff.f.....f
+..f...f....f....
+.f....f.f....f..
+`}
+	w, err := NewWorld(WithSize(16, 6), WithFish(24), WithSharks(6), WithThreads(1), WithSeed(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, g := range golden {
+		if i > 0 {
+			step(t, w, 5)
+		}
+		want := strings.TrimPrefix(g, "\n")
+		if got := w.String(); got != want {
+			t.Errorf("tick %d: String() =\n%s\nwant\n%s", w.Tick(), got, want)
+		}
+		var b strings.Builder
+		w.Do(func() {
+			if err := WriteText(&b); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if b.String() != want {
+			t.Errorf("tick %d: WriteText() =\n%s\nwant\n%s", w.Tick(), b.String(), want)
+		}
+	}
+
+	r := newTestWorld(t, golden[0], nil)
+	if got := r.String(); got != strings.TrimPrefix(golden[0], "\n") {
+		t.Errorf("read back as\n%s", got)
+	}
+}
@@ -0,0 +1,53 @@
+package wator
+
+/// @file timers.go
+/// @brief Histograms of the creatures' breed and starve timers.
+/// @details Raw counts hide how the population is structured in time: when
+/// most fish share a breed timer they breed in waves, and sharks piling
+/// up near a starve timer of 1 show starvation pressure. `TimerHistograms()`
+/// counts, in one pass over the grid, how many fish have each breed timer
+/// value and how many sharks have each breed and starve timer value. A
+/// school counts once per fish, since its fish share the cell's timers.
+/// `-dump-timers` writes them as CSV.
+
+// / @brief Timer values at or above this are counted in the last bin.
+const maxTimerBin = 1 << 16
+
+// / @brief Creature counts by timer value; index i counts the value i.
+type TimerHist struct {
+	FishBreed   []int
+	SharkBreed  []int
+	SharkStarve []int
+}
+
+// / @brief Count `n` creatures with timer value `v` in `bins`.
+func addTimer(bins []int, v, n int) []int {
+	if v < 0 {
+		v = 0
+	}
+	if v >= maxTimerBin {
+		v = maxTimerBin - 1
+	}
+	for len(bins) <= v {
+		bins = append(bins, 0)
+	}
+	bins[v] += n
+	return bins
+}
+
+// / @brief Histograms of the current timers.
+// / @return TimerHist Fish breed and shark breed/starve counts; the bins of
+// / each add up to the fish or shark population.
+func TimerHistograms() TimerHist {
+	var h TimerHist
+	for i, s := range Grid {
+		switch s {
+		case 1:
+			h.FishBreed = addTimer(h.FishBreed, BreedTimer[i], fishIn(i))
+		case 2:
+			h.SharkBreed = addTimer(h.SharkBreed, BreedTimer[i], 1)
+			h.SharkStarve = addTimer(h.SharkStarve, StarveTimer[i], 1)
+		}
+	}
+	return h
+}
@@ -0,0 +1,45 @@
+package wator
+
+import "testing"
+
+// / @brief The timer histograms add up to the populations every tick and
+// / no timer lies outside [0, param + jitter].
+func TestTimerHistograms(t *testing.T) {
+	const fishBreed, sharkBreed, sharkStarve, jitter = 5, 9, 4, 2
+	w, err := NewWorld(WithSize(50, 30), WithFish(500), WithSharks(100), WithThreads(1), WithSeed(2),
+		WithFishBreed(fishBreed), WithSharkBreed(sharkBreed), WithSharkStarve(sharkStarve))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Load()
+	defer w.Unload()
+	FishBreedJitter, SharkBreedJitter = jitter, jitter
+	if err := ValidateConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(tick int, name string, bins []int, max, want int) {
+		sum := 0
+		for v, n := range bins {
+			if n > 0 && v > max {
+				t.Errorf("tick %d: %d %s at timer %d, above %d", tick, n, name, v, max)
+			}
+			sum += n
+		}
+		if sum != want {
+			t.Errorf("tick %d: %s bins add up to %d, want %d", tick, name, sum, want)
+		}
+	}
+	for tick := 0; tick <= 60; tick++ {
+		if tick > 0 {
+			step(t, w, 1)
+		}
+		h := TimerHistograms()
+		check(tick, "fish breed", h.FishBreed, fishBreed+jitter, w.Fish())
+		check(tick, "shark breed", h.SharkBreed, sharkBreed+jitter, w.Sharks())
+		check(tick, "shark starve", h.SharkStarve, sharkStarve, w.Sharks())
+	}
+	if w.Fish() == 0 || w.Sharks() == 0 {
+		t.Fatalf("world went extinct (fish %d, sharks %d); the check saw too little", w.Fish(), w.Sharks())
+	}
+}
@@ -0,0 +1,81 @@
+package wator
+
+/// @file timing.go
+/// @brief Per-tile timing of the parallel update to reveal load imbalance.
+/// @details With `-tile-timing` every tile goroutine records how long it
+/// ran. `tileTimes` holds one entry per tile launched in the last tick;
+/// the min/mean/max go into the `-ndjson` metrics and are logged every
+/// `tileTimingEvery` ticks. Without the flag no clock is read.
+
+import (
+	"log"
+	"time"
+)
+
+// / @brief Measure the tile goroutines.
+var TileTiming bool = false
+
+// / @brief Run time of every tile launched in the last tick.
+var tileTimes []time.Duration
+
+// / @brief Log the tile timing summary every N ticks.
+const tileTimingEvery = 100
+
+var tilesLaunched int
+
+// / @brief Make room for up to `n` tiles; called before they are launched.
+// / @details The slice header must not change while goroutines write their
+// / slots, so its full length is set up front and trimmed to the launched
+// / tiles by `finishTileTimes()` after they are done.
+func resetTileTimes(n int) {
+	if cap(tileTimes) < n {
+		tileTimes = make([]time.Duration, n)
+	}
+	tileTimes = tileTimes[:n]
+	tilesLaunched = 0
+}
+
+// / @brief Reserve the slot of the next launched tile.
+func nextTileSlot() int {
+	tilesLaunched++
+	return tilesLaunched - 1
+}
+
+// / @brief Drop the slots of tiles that were not launched; after `wg.Wait()`.
+func finishTileTimes() {
+	tileTimes = tileTimes[:tilesLaunched]
+	logTileTiming()
+}
+
+// / @brief Minimum, mean and maximum of the last tick's tile times.
+func tileTimeStats() (min, mean, max time.Duration) {
+	if len(tileTimes) == 0 {
+		return 0, 0, 0
+	}
+	var sum time.Duration
+	min = tileTimes[0]
+	for _, d := range tileTimes {
+		sum += d
+		if d < min {
+			min = d
+		}
+		if d > max {
+			max = d
+		}
+	}
+	return min, sum / time.Duration(len(tileTimes)), max
+}
+
+// / @brief Log the summary if this tick is due; called after each tick.
+func logTileTiming() {
+	if Tick%tileTimingEvery != 0 {
+		return
+	}
+	min, mean, max := tileTimeStats()
+	imbalance := 0.0
+	if mean > 0 {
+		imbalance = float64(max) / float64(mean)
+	}
+	log.Printf("tick %d: %d tiles, min %v, mean %v, max %v (max/mean %.2f)",
+		Tick, len(tileTimes), min, mean, max, imbalance)
+}
@@ -0,0 +1,60 @@
+package wator
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// / @brief Every tick records one run time per tile it launched, leaving out
+// / the empty tiles a rounded-up layout can have.
+func TestTileTimesPerTile(t *testing.T) {
+	for _, tc := range []struct {
+		sched         string
+		threads, w, h int
+		tiles         int // 0: take the active tiles of the layout
+	}{
+		{SchedStatic, 1, 10, 4, 1},
+		{SchedStatic, 4, 10, 8, 4},
+		{SchedStatic, 5, 10, 4, 4}, // 2x3 tiles of 5x2, the bottom row empty
+		{SchedQueue, 2, 40, 40, 8},
+		{SchedPhased, 3, 40, 10, 0},
+	} {
+		t.Run(fmt.Sprintf("%s/threads=%d/%dx%d", tc.sched, tc.threads, tc.w, tc.h), func(t *testing.T) {
+			w, err := NewWorld(WithSize(tc.w, tc.h), WithFish(tc.w*tc.h/4), WithSharks(tc.w*tc.h/10),
+				WithThreads(tc.threads), WithSeed(2))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Load()
+			defer w.Unload()
+			TileTiming = true
+			if err := SetScheduler(tc.sched); err != nil {
+				t.Fatal(err)
+			}
+			want := tc.tiles
+			if want == 0 {
+				want = CountActiveTiles(TileLayout())
+			}
+			for i := 0; i < 3; i++ {
+				if err := Update(); err != nil {
+					t.Fatal(err)
+				}
+				if len(tileTimes) != want {
+					t.Fatalf("tick %d recorded %d tile times, want %d", Tick, len(tileTimes), want)
+				}
+				var total time.Duration
+				for _, d := range tileTimes {
+					total += d
+				}
+				if total <= 0 {
+					t.Errorf("tick %d: the tiles ran for %v in all", Tick, total)
+				}
+				st := CollectStats()
+				if st.TileMinUS > st.TileMeanUS || st.TileMeanUS > st.TileMaxUS {
+					t.Errorf("tick %d: min %d, mean %d, max %d us out of order", Tick, st.TileMinUS, st.TileMeanUS, st.TileMaxUS)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package wator
+
+/// @file vision.go
+/// @brief Sharks that spot fish farther away than their neighbors.
+/// @details With `-shark-vision R` (R > 1) a shark that finds no fish next
+/// to it looks for the nearest fish within Manhattan distance R, the number
+/// of single steps needed to reach it, and tries the steps that bring it
+/// closer first. It still moves one cell per tick through the usual locked
+/// move, so only the choice of direction changes. Detection reads
+/// `visionGrid`, a copy of `Grid` taken before the tiles start, so it can
+/// look across tile borders without locks and without racing with other
+/// workers; it sees where the fish were at the start of the tick. Ties
+/// between equally near fish are broken with the tile's random source.
+/// The default R = 1 keeps the classic rules and draws no extra random
+/// numbers.
+
+// / @brief Manhattan distance up to which sharks notice fish.
+var SharkVision int = 1
+
+// / @brief Start-of-tick copy of `Grid` for shark detection and greedy
+// / hunting (hunt.go).
+var visionGrid []uint8
+
+// / @brief Take the copy of `Grid` sharks look at during this tick.
+func prepareVision() {
+	if stalePlane(visionGrid) {
+		visionGrid = NewPlane[uint8]()
+	}
+	CopyPlane(visionGrid, Grid)
+}
+
+// / @brief Reorder a shark's directions so steps toward prey come first.
+// / @details Looks for the nearest fish at Manhattan distance 2 to
+// / `SharkVision` (adjacent fish are handled by the eat loop). Directions
+// / that shorten the distance to it move to the front, keeping their
+// / relative order; nothing changes if no fish is in sight.
+// / @param x Shark x coordinate.
+// / @param y Shark y coordinate.
+// / @param directions Candidate directions, reordered in place.
+// / @param rng The tile's random source.
+func steerToPrey(x, y int, directions [][2]int, rng tileRand) {
+	for r := 2; r <= SharkVision; r++ {
+		// count the fish on the ring first, then pick one at random
+		n := 0
+		ring := func(visit func(dx, dy int) bool) {
+			for dx := -r; dx <= r; dx++ {
+				rest := r - abs(dx)
+				for _, dy := range [2]int{-rest, rest} {
+					cx, cy := wrapCoords(x+dx, y+dy)
+					if visionGrid[Index(cx, cy)] == 1 && visit(dx, dy) {
+						return
+					}
+					if rest == 0 {
+						break
+					}
+				}
+			}
+		}
+		ring(func(dx, dy int) bool { n++; return false })
+		if n == 0 {
+			continue
+		}
+		k := rng.Intn(n)
+		ring(func(dx, dy int) bool {
+			if k > 0 {
+				k--
+				return false
+			}
+			towardFirst(directions, dx, dy)
+			return true
+		})
+		return
+	}
+}
+
+// / @brief Move the directions that reduce the offset (dx, dy) to the front.
+func towardFirst(directions [][2]int, dx, dy int) {
+	toward := func(d [2]int) bool {
+		return (d[0] != 0 && d[0]*dx > 0) || (d[1] != 0 && d[1]*dy > 0)
+	}
+	i := 0
+	for j, d := range directions {
+		if toward(d) {
+			copy(directions[i+1:j+1], directions[i:j])
+			directions[i] = d
+			i++
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
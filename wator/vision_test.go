@@ -0,0 +1,61 @@
+package wator
+
+import "testing"
+
+// / @brief A shark with vision 3 heads for a fish three cells away and eats
+// / it; with vision 1, or with the fish one cell farther, it goes its fixed
+// / way north and never gets there.
+func TestSharkVisionReach(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		vision int
+		layout string
+		eaten  bool
+	}{
+		{"vision 3", 3, `
+...........
+...........
+...........
+...........
+...........
+...S..f....
+...........
+...........
+...........
+...........`, true},
+		{"vision 1", 1, `
+...........
+...........
+...........
+...........
+...........
+...S..f....
+...........
+...........
+...........
+...........`, false},
+		{"vision 3, fish at 4", 3, `
+...........
+...........
+...........
+...........
+...........
+...S...f...
+...........
+...........
+...........
+...........`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// the fish never moves, the shark tries N, E, S, W unless steered
+			w := newTestWorld(t, tc.layout, func() {
+				Deterministic, FishStayProb, NoFishBreed, NoSharkBreed = true, 1, true, true
+				SharkStarve, SharkVision = 20, tc.vision
+			})
+			step(t, w, 10)
+			if eaten := w.Fish() == 0; eaten != tc.eaten {
+				t.Errorf("fish eaten: %v, want %v", eaten, tc.eaten)
+			}
+		})
+	}
+}
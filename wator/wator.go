@@ -0,0 +1,976 @@
+package wator
+
+/// @file wator.go
+/// @brief Wa-Tor predator-prey simulation core.
+/// @details This file implements the Wa-Tor simulation: fish and sharks
+/// interact on a toroidal grid. The simulation supports a multithreaded
+/// update step that partitions the grid into tiles; workers guard the
+/// cells they write near a tile border with per-cell compare-and-swap
+/// claims (claim.go).
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// / @brief Simulation settings: initial counts and timers.
+var NumShark int = 4000
+var NumFish int = 10000
+var FishBreed int = 3   // ticks before fish can breed
+var SharkBreed int = 8  // ticks before shark can breed
+var SharkStarve int = 3 // ticks a shark can go without eating
+var FishStarve int = 0  // ticks a fish survives without breeding (0 = never starves)
+var Threads int = 4     // number of worker goroutines
+
+// / @brief Movement probabilities.
+// / @details A fish stays put with probability `FishStayProb` even when it
+// / could move; a shark that did not eat moves with probability
+// / `SharkMoveProb`. The defaults (0 and 1) keep the classic rules, and the
+// / RNG is only consulted when a probability is changed, so default runs
+// / draw exactly the same random numbers as before.
+var FishStayProb float64 = 0
+var SharkMoveProb float64 = 1
+
+// / @brief Turn off reproduction of a species, for die-off studies.
+// / @details Its breed timers still count down to 0 but never reset, and
+// / every move is a plain move; schools do not grow either.
+var NoFishBreed bool = false
+var NoSharkBreed bool = false
+
+// / @brief Grid size in cells; change it with `SetSize()` (see grid.go).
+var Width int = 400
+var Height int = 400
+
+// / @brief Check the simulation settings before the world is built.
+// / @return error Describes the first invalid setting, nil if all are usable.
+func ValidateConfig() error {
+	if NumFish < 0 || NumShark < 0 {
+		return fmt.Errorf("populations must not be negative (fish=%d, sharks=%d)", NumFish, NumShark)
+	}
+	if NumFish+NumShark > Width*Height {
+		return fmt.Errorf("%d fish + %d sharks do not fit on a %dx%d grid (%d cells)",
+			NumFish, NumShark, Width, Height, Width*Height)
+	}
+	if FishBreed < 0 || SharkBreed < 0 || SharkStarve < 0 || FishStarve < 0 {
+		return fmt.Errorf("timers must not be negative (fishBreed=%d, sharkBreed=%d, sharkStarve=%d, fishStarve=%d)",
+			FishBreed, SharkBreed, SharkStarve, FishStarve)
+	}
+	if FishStayProb < 0 || FishStayProb > 1 || SharkMoveProb < 0 || SharkMoveProb > 1 {
+		return fmt.Errorf("probabilities must be within [0, 1] (fishStayProb=%g, sharkMoveProb=%g)",
+			FishStayProb, SharkMoveProb)
+	}
+	if FishBreedJitter < 0 || SharkBreedJitter < 0 {
+		return fmt.Errorf("breed jitter must not be negative (fish=%d, shark=%d)", FishBreedJitter, SharkBreedJitter)
+	}
+	if ReseedEvery < 0 || ReseedFish < 0 || ReseedSharks < 0 {
+		return fmt.Errorf("reseed settings must not be negative (every=%d, fish=%d, sharks=%d)",
+			ReseedEvery, ReseedFish, ReseedSharks)
+	}
+	if Threads < 1 {
+		return fmt.Errorf("threads must be at least 1, got %d", Threads)
+	}
+	maxVision := Width / 2
+	if Height < Width {
+		maxVision = Height / 2
+	}
+	// a side of 1 leaves no room to look further, but the default still works
+	maxVision = max(maxVision, 1)
+	if SharkVision < 1 || SharkVision > maxVision {
+		return fmt.Errorf("shark-vision must be between 1 and %d, got %d", maxVision, SharkVision)
+	}
+	if Topology == TopoHex && SharkVision > 1 {
+		return fmt.Errorf("shark-vision above 1 is not available with -topology hex")
+	}
+	if Topology == TopoHex && Height%2 != 0 {
+		return fmt.Errorf("-topology hex needs an even grid height, got %d", Height)
+	}
+	if MaxStack < 1 || MaxStack > 255 {
+		return fmt.Errorf("max-stack must be between 1 and 255, got %d", MaxStack)
+	}
+	return nil
+}
+
+// / @brief Grid values: 0 empty, 1 fish, 2 shark
+// / @details The grid and timer planes (see grid.go) are swapped between
+// / current and next state by `Update()` without copying; code that keeps
+// / one must not hold on to it across a tick.
+var Grid = NewPlane[uint8]()
+var buffer = NewPlane[uint8]()
+
+var BreedTimer = NewPlane[int]()
+var bufferBreed = NewPlane[int]()
+
+var StarveTimer = NewPlane[int]()
+var bufferStarve = NewPlane[int]()
+
+// / @brief Fish per fish cell in `-stacking` mode (see stack.go).
+var StackCount = NewPlane[uint8]()
+var bufferStack = NewPlane[uint8]()
+
+// / @brief Number of ticks simulated since `InitWorld()`.
+var Tick int = 0
+
+// / @brief Returns the current number of fish on the grid.
+// / @return int Number of fish; with `-stacking` every fish in a school counts.
+func CountFish() int {
+	cnt := 0
+	for i, s := range Grid {
+		if s == 1 {
+			cnt += fishIn(i)
+		}
+	}
+	return cnt
+}
+
+// / @brief Returns the current number of sharks on the grid.
+// / @return int Number of cells containing a shark.
+func CountSharks() int {
+	cnt := 0
+	for _, s := range Grid {
+		if s == 2 {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// / @brief Spread of the breed timer given to creatures that just bred.
+// / @details With a jitter J the timer is drawn uniformly from
+// / [breed-J, breed+J] (clamped at 0) for both the parent and its offspring,
+// / which keeps births from synchronizing into waves. 0 keeps the exact
+// / constant and draws no random numbers.
+var FishBreedJitter int = 0
+var SharkBreedJitter int = 0
+
+// / @brief Breed timer for a creature starting a new breeding cycle.
+// / @param base The species' breed time (`FishBreed` or `SharkBreed`).
+// / @param jitter The species' jitter.
+// / @param rng The tile's random source.
+// / @return int The new breed timer.
+func freshBreed(base, jitter int, rng tileRand) int {
+	if jitter <= 0 {
+		return base
+	}
+	t := base - jitter + rng.Intn(2*jitter+1)
+	if t < 0 {
+		t = 0
+	}
+	return t
+}
+
+// / @brief Advance a breed or starve timer by one tick.
+// / @details Timers count down to 0 and stay there, so a creature that could
+// / not act on an expired timer keeps it expired instead of going negative.
+// / @param t The timer's current value.
+// / @return int The value for the next tick.
+func ageTimer(t int) int {
+	if t <= 1 {
+		return 0
+	}
+	return t - 1
+}
+
+// / @brief Reset the next-state buffers before a tick.
+// / @details The rows are split into one stripe per worker and cleared
+// / concurrently on the worker pool; the tiles only start once every
+// / stripe is done, since they also write into neighboring tiles.
+// / @param workers Number of stripes to split the rows into.
+func clearBuffers(workers int) {
+	fp := TrackFingerprint && !stalePlane(cellFP)
+	clearStripe := func(sy, ey int) {
+		a, b := sy*Width, ey*Width
+		clear(buffer[a:b])
+		clear(bufferBreed[a:b])
+		clear(bufferStarve[a:b])
+		clear(bufferStack[a:b])
+		if fp {
+			clear(cellFP[a:b])
+		}
+	}
+	if workers <= 1 {
+		clearStripe(0, Height)
+		return
+	}
+
+	stripe := (Height + workers - 1) / workers
+	runPool((Height+stripe-1)/stripe, func(i int) {
+		clearStripe(i*stripe, min((i+1)*stripe, Height))
+	})
+}
+
+// / @brief Outcomes of `resolveConflict`.
+const (
+	claimFree    = iota // nobody has claimed the cell yet: take it
+	claimEat            // a fish got there first and the arriving shark eats it
+	claimEaten          // a shark got there first and eats the arriving fish
+	claimBlocked        // claimed by the same species: try another direction
+)
+
+// / @brief Decide who gets a cell that was empty at the start of the tick.
+// / @details Several creatures in different tiles may move to the same empty
+// / cell. Sharks always win against fish, independent of which one is
+// / processed first: a shark arriving after a fish eats it, and a fish
+// / arriving after a shark is eaten, so both orders end with a fed shark in
+// / the cell. Between creatures of the same species the first claim wins.
+// / A starving shark dies before it can claim anything. The caller holds
+// / the cell's claim (see claim.go).
+// / @param c The cell's index.
+// / @param arriving 1 for a fish, 2 for a shark.
+// / @return int One of the claim constants.
+func resolveConflict(c int, arriving uint8) int {
+	switch claimed := buffer[c]; {
+	case claimed == 0:
+		return claimFree
+	case claimed == arriving:
+		return claimBlocked
+	case arriving == 2:
+		return claimEat
+	default:
+		return claimEaten
+	}
+}
+
+// / @brief Evaluate neighbors in a fixed N, E, S, W order instead of shuffling.
+var Deterministic bool = false
+
+// / @brief The order in which a creature in row `y` tries its neighbors.
+// / @details Normally a random permutation; in `Deterministic` mode the
+// / canonical N, E, S, W order (y grows downwards), so no RNG is used. On
+// / the hex grid the fixed order runs clockwise from the upper right (see
+// / hex.go).
+// / @param y The creature's row, which selects the hex neighbors.
+// / @param rng The tile's random source.
+// / @return [][2]int A fresh slice of (dx, dy) offsets the caller may reorder.
+func neighborOrder(y int, rng tileRand) [][2]int {
+	if Deterministic && Topology != TopoHex {
+		return [][2]int{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+	}
+	directions := neighborOffsets(y)
+	if Deterministic {
+		return directions
+	}
+	rng.Shuffle(len(directions), func(i, j int) {
+		directions[i], directions[j] = directions[j], directions[i]
+	})
+	return directions
+}
+
+// / @brief Number of workers `Update()` runs with: `Threads`, capped at the
+// / grid width.
+func WorkerThreads() int {
+	if Threads > Width {
+		return Width
+	}
+	return Threads
+}
+
+// / @brief The tile layout `Update()` uses for the current settings.
+// / @details Combines `WorkerThreads()`, the scheduler's tile count (see
+// / sched.go) and `tileLayout()`, or takes the fixed stripes of the
+// / `phased` scheduler. This is the only place that does, so `Update()`,
+// / the tile overlay and the run plan always agree.
+// / @return cols, rows Number of tile columns and rows.
+// / @return tileW, tileH Size of a (full) tile in cells.
+func TileLayout() (cols, rows, tileW, tileH int) {
+	if Scheduler == SchedPhased {
+		return phasedLayout()
+	}
+	return tileLayout(tileCount(WorkerThreads()))
+}
+
+// / @brief Compute how `Update()` partitions the grid for `thr` workers.
+// / @details Picks a tile grid close to a square of `thr` workers. Tiles at
+// / the right/bottom edge may be smaller, or empty when the sizes round up.
+// / @param thr Number of worker threads.
+// / @return cols, rows Number of tile columns and rows.
+// / @return tileW, tileH Size of a (full) tile in cells.
+func tileLayout(thr int) (cols, rows, tileW, tileH int) {
+	cols = int(math.Sqrt(float64(thr)))
+	if cols <= 0 {
+		cols = 1
+	}
+	rows = (thr + cols - 1) / cols
+	if rows <= 0 {
+		rows = 1
+	}
+
+	tileW = (Width + cols - 1) / cols
+	tileH = (Height + rows - 1) / rows
+	return cols, rows, tileW, tileH
+}
+
+// / @brief Cell range covered by tile (tx, ty) of a `tileLayout()`.
+// / @param tx Tile column.
+// / @param ty Tile row.
+// / @param tileW Tile width from `tileLayout()`.
+// / @param tileH Tile height from `tileLayout()`.
+// / @return startX, endX, startY, endY Half-open cell range of the tile.
+// / @return ok False if the tile holds no cells (it lies past the grid edge).
+func TileBounds(tx, ty, tileW, tileH int) (startX, endX, startY, endY int, ok bool) {
+	startX = tx * tileW
+	endX = startX + tileW
+	if endX > Width {
+		endX = Width
+	}
+	startY = ty * tileH
+	endY = startY + tileH
+	if endY > Height {
+		endY = Height
+	}
+	return startX, endX, startY, endY, startX < endX && startY < endY
+}
+
+// / @brief Number of tiles of a `tileLayout()` that hold at least one cell.
+func CountActiveTiles(cols, rows, tileW, tileH int) int {
+	active := 0
+	for tx := 0; tx < cols; tx++ {
+		for ty := 0; ty < rows; ty++ {
+			if _, _, _, _, ok := TileBounds(tx, ty, tileW, tileH); ok {
+				active++
+			}
+		}
+	}
+	return active
+}
+
+// / @brief Compute the next simulation tick.
+// / @details update() builds the next world state in `buffer` and then
+// / swaps buffers into `Grid`. The function partitions the grid into tiles
+// / and hands them to the worker pool (pool.go) to process in parallel. Per-cell claims
+// / (see claim.go) protect concurrent writes into `buffer` and timer
+// / arrays. Fish try to move/breed into empty neighbors; sharks try to eat
+// / adjacent fish first, otherwise move or possibly starve. With
+// / `FishStarve` set, fish also starve unless they breed in time. Each
+// / creature uses the parameters of its zone (see zones.go).
+// /
+// / Claims: a creature at (x,y) only ever writes its own cell and one
+// / neighbor (nx,ny), and every such write happens while both cells are
+// / claimed. A fish claims the pair for each neighbor it tries (or the own
+// / cell alone for stay-in-place); a shark claims its cell and all of its
+// / neighbors at once and makes its whole decision, eat, move or stay, in
+// / one scan under those claims. Cells are claimed in ascending index order.
+// / With the `phased` scheduler, tiles that run at the same time never
+// / share a cell, so the result does not depend on timing; the `owned`
+// / one leaves every creature that could reach another tile to a serial
+// / pass after the tiles (owned.go), so nothing needs a claim.
+// / The only write to `Grid` is a shark eating a fish, so a fish re-checks
+// / its own cell of `Grid` under its claim before committing, and clears it once
+// / it has moved so it cannot be eaten a second time at its old position.
+// / @return error Always returns nil (placeholder for potential error handling).
+func Update() error {
+	// the worker pool and buffer clearing use the same cap as the layout
+	Threads = WorkerThreads()
+	resizePool(Threads)
+
+	clearBuffers(Threads)
+
+	tileCols, tileRows, tileW, tileH := TileLayout()
+
+	if Engine == EngineAuto {
+		Sparse = autoSparse()
+	}
+	if Sparse {
+		prepareWorklist(tileCols, tileRows, tileW, tileH)
+	} else {
+		// the scan does not keep the lists up to date
+		workValid = false
+	}
+	resetFilled(tileCols * tileRows)
+
+	prepareClaims()
+
+	// pair lists a cell and one neighbor in ascending order for
+	// claimCells, once if they are the same cell (on a one-cell-wide torus)
+	pair := func(ax, ay, bx, by int) (cells [2]int, n int) {
+		a, b := ay*Width+ax, by*Width+bx
+		switch {
+		case a == b:
+			return [2]int{a}, 1
+		case a < b:
+			return [2]int{a, b}, 2
+		}
+		return [2]int{b, a}, 2
+	}
+
+	// neighborhood lists a cell and its neighbors in ascending order for
+	// claimCells: five cells, seven on the hex grid, fewer where a tiny
+	// torus wraps onto itself
+	neighborhood := func(x, y int) (cells [7]int, n int) {
+		add := func(cx, cy int) {
+			c := cy*Width + cx
+			i := n
+			for ; i > 0 && cells[i-1] >= c; i-- {
+				if cells[i-1] == c {
+					return
+				}
+			}
+			copy(cells[i+1:n+1], cells[i:n])
+			cells[i] = c
+			n++
+		}
+		add(x, y)
+		for _, d := range neighborOffsets(y) {
+			add((x+d[0]+Width)%Width, (y+d[1]+Height)%Height)
+		}
+		return cells, n
+	}
+
+	if TileTiming {
+		resetTileTimes(tileCols * tileRows)
+	}
+	if tileOrder == OrderRandom {
+		prepareOrder(tileCols * tileRows)
+	}
+	if SharkVision > 1 || SharkHunt == HuntGreedy {
+		prepareVision()
+	}
+	events := eventsEnabled()
+	if events {
+		resetEvents(tileCols * tileRows)
+	}
+	rollingValid = false
+	if TrackFingerprint {
+		resetRollingFP(tileCols * tileRows)
+	}
+	owned := Scheduler == SchedOwned
+	if owned {
+		prepareOutboxes(tileCols * tileRows)
+	}
+
+	// Collect one job per tile; runTileJobs hands them to the workers
+	jobs := tileJobs[:0]
+	for tx := 0; tx < tileCols; tx++ {
+		for ty := 0; ty < tileRows; ty++ {
+			startX, endX, startY, endY, ok := TileBounds(tx, ty, tileW, tileH)
+			// Skip empty tiles
+			if !ok {
+				continue
+			}
+
+			rng := tileRNG(tx*tileRows + ty)
+			slot := -1
+			if TileTiming {
+				slot = nextTileSlot()
+			}
+
+			run := func(sx, ex, sy, ey, ttx, tty int, rng tileRand, slot int) {
+				if slot >= 0 {
+					start := time.Now()
+					defer func() { tileTimes[slot] = time.Since(start) }()
+				}
+
+				// occupy puts a creature into next-state cell `c` (and the
+				// next worklist); the caller holds the cell's claim.
+				occupy := func(c int, state uint8) {
+					if buffer[c] == 0 {
+						tileFilled[ttx*tileRows+tty]++
+						if Sparse {
+							id := ttx*tileRows + tty
+							nextWork[id] = append(nextWork[id], int32(c))
+						}
+						if occupancy != nil {
+							occupancy[c]++
+						}
+					}
+					buffer[c] = state
+				}
+
+				// rehash folds the new contents of next-state cell `c` into
+				// the rolling fingerprint; call it after writing the cell,
+				// under its claim
+				rehash := func(c int) {
+					if !TrackFingerprint {
+						return
+					}
+					h := cellHash(c, buffer[c], bufferBreed[c], bufferStarve[c])
+					tileFP[ttx*tileRows+tty] ^= cellFP[c] ^ h
+					cellFP[c] = h
+				}
+
+				// emit records `n` events for dispatchEvents, one per fish
+				// of a school
+				emit := func(kind uint8, cx, cy int, species uint8, n int) {
+					if !events {
+						return
+					}
+					id := ttx*tileRows + tty
+					for i := 0; i < n; i++ {
+						tileEvents[id] = append(tileEvents[id], cellEvent{kind, species, int32(cx), int32(cy)})
+					}
+				}
+
+				// meal records a shark eating the `n` fish at (cx, cy)
+				meal := func(cx, cy, n int) {
+					emit(eventEat, cx, cy, 2, 1)
+					emit(eventDeath, cx, cy, 1, n)
+				}
+
+				// with -rng cell every creature restarts the stream
+				cell, _ := rng.(*cellRand)
+
+				step := func(x, y int) {
+					// Edge cells can be eaten by a shark working in a
+					// neighboring tile, which clears `Grid` under the
+					// cell's claim, so read them under it as well.
+					// Owned tiles never reach into each other.
+					i := y*Width + x
+					var state uint8
+					if !owned && (x == sx || x == ex-1 || y == sy || y == ey-1) {
+						claimCell(i)
+						state = Grid[i]
+						releaseCell(i)
+					} else {
+						state = Grid[i]
+					}
+					if state == 0 {
+						return
+					}
+
+					// Only cells within two of the tile's edges can be
+					// reached by a creature of another tile; deeper in,
+					// the tile's own goroutine is alone and claims nothing.
+					shared := x < sx+2 || x >= ex-2 || y < sy+2 || y >= ey-2
+					if owned {
+						// owned tiles leave their border to the serial
+						// merge pass, which needs no claims either
+						if shared && !merging {
+							id := ttx*tileRows + tty
+							outbox[id] = append(outbox[id], int32(i))
+							return
+						}
+						shared = false
+					}
+
+					if cell != nil {
+						cell.at(x, y)
+					}
+					p := paramsAt(x, y)
+
+					// Fish behavior
+					if state == 1 {
+						directions := neighborOrder(y, rng)
+
+						moved := false
+						eaten := false
+						newBreed := ageTimer(BreedTimer[i])
+						breeds := newBreed <= 0 && !NoFishBreed
+						newStarve := 0
+						if p.fishStarve > 0 {
+							newStarve = ageTimer(StarveTimer[i])
+						}
+
+						// hesitate: stay put even if a move is available
+						if FishStayProb > 0 && rng.Float64() < FishStayProb {
+							directions = nil
+						}
+
+						for _, dir := range directions {
+							nx := (x + dir[0] + Width) % Width
+							ny := (y + dir[1] + Height) % Height
+							ni := ny*Width + nx
+
+							// claim the own and the target cell
+							var cells [2]int
+							nCells := 0
+							if shared {
+								cells, nCells = pair(x, y, nx, ny)
+								claimCells(cells[:nCells])
+							}
+
+							claim := claimBlocked
+							if Grid[ni] == 0 {
+								claim = resolveConflict(ni, 1)
+							}
+
+							if Grid[i] != 1 {
+								// eaten by a neighboring tile's shark since we looked
+								eaten = true
+							} else if claim != claimBlocked {
+								n := StackCount[i]
+								if breeds && Stacking && int(n) < MaxStack {
+									// school: the newborn joins the moving stack
+									if claim == claimEaten {
+										bufferStarve[ni] = p.sharkStarve
+										rehash(ni)
+										meal(nx, ny, int(n))
+									} else {
+										occupy(ni, 1)
+										bufferBreed[ni] = freshBreed(p.fishBreed, FishBreedJitter, rng)
+										bufferStarve[ni] = p.fishStarve
+										rehash(ni)
+										bufferStack[ni] = n + 1
+										emit(eventBirth, nx, ny, 1, 1)
+									}
+								} else if breeds {
+									// breed: leave offspring and reset parent timers
+									if buffer[i] == 0 {
+										occupy(i, 1)
+										bufferBreed[i] = freshBreed(p.fishBreed, FishBreedJitter, rng)
+										bufferStarve[i] = p.fishStarve
+										rehash(i)
+										bufferStack[i] = 1
+										emit(eventBirth, x, y, 1, 1)
+									}
+									if claim == claimEaten {
+										bufferStarve[ni] = p.sharkStarve
+										rehash(ni)
+										meal(nx, ny, int(n))
+									} else {
+										occupy(ni, 1)
+										bufferBreed[ni] = freshBreed(p.fishBreed, FishBreedJitter, rng)
+										bufferStarve[ni] = p.fishStarve
+										rehash(ni)
+										bufferStack[ni] = n
+									}
+								} else if p.fishStarve > 0 && newStarve <= 0 {
+									// starved without breeding: the fish dies
+									emit(eventDeath, x, y, 1, int(n))
+								} else if claim == claimEaten {
+									bufferStarve[ni] = p.sharkStarve
+									rehash(ni)
+									meal(nx, ny, int(n))
+								} else {
+									// move with decremented timers
+									occupy(ni, 1)
+									bufferBreed[ni] = newBreed
+									bufferStarve[ni] = newStarve
+									rehash(ni)
+									bufferStack[ni] = n
+								}
+								// the fish has been handled: a shark must not
+								// eat it at its old position any more
+								Grid[i] = 0
+								moved = true
+							}
+
+							releaseCells(cells[:nCells])
+
+							if moved || eaten {
+								break
+							}
+						}
+
+						if !moved && !eaten {
+							// claim only the own cell to write stay-in-place
+							if shared {
+								claimCell(i)
+							}
+							if Grid[i] == 1 && p.fishStarve > 0 && newStarve <= 0 {
+								// starved in place
+								Grid[i] = 0
+								emit(eventDeath, x, y, 1, int(StackCount[i]))
+							} else if Grid[i] == 1 && buffer[i] == 0 {
+								occupy(i, 1)
+								n := StackCount[i]
+								if breeds && Stacking && int(n) < MaxStack {
+									// a school grows in place as well
+									n++
+									newBreed = freshBreed(p.fishBreed, FishBreedJitter, rng)
+									newStarve = p.fishStarve
+									emit(eventBirth, x, y, 1, 1)
+								}
+								bufferBreed[i] = newBreed
+								bufferStarve[i] = newStarve
+								rehash(i)
+								bufferStack[i] = n
+							}
+							if shared {
+								releaseCell(i)
+							}
+						}
+
+						// Shark behavior
+					} else if state == 2 {
+						directions := neighborOrder(y, rng)
+
+						if SharkHunt == HuntGreedy {
+							rankPrey(x, y, directions)
+						}
+
+						newBreed := ageTimer(BreedTimer[i])
+						breeds := newBreed <= 0 && !NoSharkBreed
+						newStarve := ageTimer(StarveTimer[i])
+
+						// One scan under one set of claims: the own cell
+						// and every neighbor are held while the shark finds
+						// the first adjacent fish and the first free cell,
+						// then eats, moves or stays, in that preference.
+						var cells [7]int
+						nCells := 0
+						if shared {
+							cells, nCells = neighborhood(x, y)
+							claimCells(cells[:nCells])
+						}
+
+						prey, free := -1, -1
+						freeClaim := claimBlocked
+						for d, dir := range directions {
+							nx := (x + dir[0] + Width) % Width
+							ny := (y + dir[1] + Height) % Height
+							ni := ny*Width + nx
+							if Grid[ni] == 1 && buffer[ni] == 0 {
+								prey = d
+								break
+							}
+							if free < 0 && Grid[ni] == 0 {
+								if c := resolveConflict(ni, 2); c != claimBlocked {
+									free, freeClaim = d, c
+								}
+							}
+						}
+						if prey < 0 && SharkVision > 1 {
+							// steering reorders the directions, so take the
+							// first free cell in the new order
+							steerToPrey(x, y, directions, rng)
+							free = -1
+							for d, dir := range directions {
+								nx := (x + dir[0] + Width) % Width
+								ny := (y + dir[1] + Height) % Height
+								ni := ny*Width + nx
+								if Grid[ni] == 0 {
+									if c := resolveConflict(ni, 2); c != claimBlocked {
+										free, freeClaim = d, c
+										break
+									}
+								}
+							}
+						}
+
+						moved := false
+						if prey >= 0 {
+							nx := (x + directions[prey][0] + Width) % Width
+							ny := (y + directions[prey][1] + Height) % Height
+							ni := ny*Width + nx
+							if StackCount[ni] > 1 {
+								// eat one fish of a school; the rest stays, and
+								// so does the shark, waiting to breed if due
+								newStarve = p.sharkStarve
+								StackCount[ni]--
+								meal(nx, ny, 1)
+								if buffer[i] == 0 {
+									occupy(i, 2)
+									bufferBreed[i] = newBreed
+									bufferStarve[i] = newStarve
+									rehash(i)
+								}
+							} else {
+								// eat: reset starvation and clear eaten fish
+								newStarve = p.sharkStarve
+								// mark eaten fish in original grid (reading other goroutines still read original grid)
+								Grid[ni] = 0
+								meal(nx, ny, 1)
+
+								if breeds {
+									if buffer[i] == 0 {
+										occupy(i, 2)
+										bufferBreed[i] = freshBreed(p.sharkBreed, SharkBreedJitter, rng)
+										bufferStarve[i] = p.sharkStarve
+										rehash(i)
+										emit(eventBirth, x, y, 2, 1)
+									}
+									occupy(ni, 2)
+									bufferBreed[ni] = freshBreed(p.sharkBreed, SharkBreedJitter, rng)
+									bufferStarve[ni] = newStarve
+									rehash(ni)
+								} else {
+									occupy(ni, 2)
+									bufferBreed[ni] = newBreed
+									bufferStarve[ni] = newStarve
+									rehash(ni)
+								}
+							}
+							moved = true
+						} else if (SharkMoveProb >= 1 || rng.Float64() < SharkMoveProb) && free >= 0 {
+							// move to the free cell (unless the shark hesitated,
+							// see sharkMoveProb; the draw happens either way)
+							nx := (x + directions[free][0] + Width) % Width
+							ny := (y + directions[free][1] + Height) % Height
+							ni := ny*Width + nx
+							if freeClaim == claimEat && newStarve > 0 {
+								newStarve = p.sharkStarve
+								meal(nx, ny, int(bufferStack[ni]))
+							}
+							// if starved, shark dies (do not write)
+							if newStarve <= 0 {
+								emit(eventDeath, x, y, 2, 1)
+							} else if breeds {
+								// breed: leave newborn and reset parent
+								if buffer[i] == 0 {
+									occupy(i, 2)
+									bufferBreed[i] = freshBreed(p.sharkBreed, SharkBreedJitter, rng)
+									bufferStarve[i] = p.sharkStarve
+									rehash(i)
+									emit(eventBirth, x, y, 2, 1)
+								}
+								occupy(ni, 2)
+								bufferBreed[ni] = freshBreed(p.sharkBreed, SharkBreedJitter, rng)
+								bufferStarve[ni] = newStarve
+								rehash(ni)
+							} else {
+								// normal move
+								occupy(ni, 2)
+								bufferBreed[ni] = newBreed
+								bufferStarve[ni] = newStarve
+								rehash(ni)
+							}
+							moved = true
+						}
+
+						// A shark that neither ate nor moved still ages and
+						// starves, but never breeds in place: with an expired
+						// breed timer it waits at 0 and breeds on its next
+						// move or meal.
+						if !moved {
+							// stay or die if starved
+							if newStarve <= 0 {
+								emit(eventDeath, x, y, 2, 1)
+							} else if buffer[i] == 0 {
+								occupy(i, 2)
+								bufferBreed[i] = newBreed
+								bufferStarve[i] = newStarve
+								rehash(i)
+							}
+						}
+						releaseCells(cells[:nCells])
+					}
+				}
+
+				if owned {
+					outboxStep[ttx*tileRows+tty] = step
+				}
+				visitTile(ttx*tileRows+tty, sx, ex, sy, ey, rng, step)
+			}
+			jobs = append(jobs, tileJob{run, startX, endX, startY, endY, tx, ty, rng, slot})
+		}
+	}
+
+	runTileJobs(jobs)
+	tileJobs = jobs
+	if owned {
+		mergeOutboxes()
+	}
+	finishFilled()
+
+	// Swap grids and timer arrays
+	Grid, buffer = buffer, Grid
+	BreedTimer, bufferBreed = bufferBreed, BreedTimer
+	StarveTimer, bufferStarve = bufferStarve, StarveTimer
+	StackCount, bufferStack = bufferStack, StackCount
+
+	if Sparse {
+		finishWorklist()
+	}
+
+	Tick++
+	if TileTiming {
+		finishTileTimes()
+	}
+	if TrackFingerprint {
+		if err := finishRollingFP(); err != nil {
+			return err
+		}
+	}
+	if events {
+		dispatchEvents()
+	}
+	if ReseedEvery > 0 && Tick%ReseedEvery == 0 {
+		reseed(ReseedFish, ReseedSharks)
+	}
+
+	//fmt.Printf("Fish: %d\n", countFish())
+
+	return nil
+}
+
+// / @brief Place a new creature with fresh timers.
+// / @param x Cell x coordinate.
+// / @param y Cell y coordinate.
+// / @param state 1 for a fish, 2 for a shark.
+func spawn(x, y int, state uint8) {
+	i := Index(x, y)
+	Grid[i] = state
+	StarveTimer[i] = 0
+	StackCount[i] = 0
+	p := paramsAt(x, y)
+	if state == 1 {
+		StackCount[i] = 1
+		BreedTimer[i] = p.fishBreed
+		StarveTimer[i] = p.fishStarve
+	} else {
+		BreedTimer[i] = p.sharkBreed
+		StarveTimer[i] = p.sharkStarve
+	}
+}
+
+// / @brief Initialize the world grid and timers.
+// / @details Clears the grid and places `NumFish` fish and `NumShark` sharks
+// / at random, using fixed breed/starve timers defined by `FishBreed` and
+// / `SharkBreed`/`SharkStarve` (random ones with `RandomizeInitialTimers`).
+// / Resets the tick counter.
+func InitWorld() {
+	Tick = 0
+	InvalidateWorklist()
+
+	// Clear everything
+	clear(Grid)
+	clear(BreedTimer)
+	clear(StarveTimer)
+
+	// Place initial fish
+	for i := 0; i < NumFish; i++ {
+		x := rnd.Intn(Width)
+		y := rnd.Intn(Height)
+		if Grid[Index(x, y)] == 0 {
+			spawn(x, y, 1)
+		} else {
+			i--
+		}
+	}
+
+	// Place initial sharks
+	for i := 0; i < NumShark; i++ {
+		x := rnd.Intn(Width)
+		y := rnd.Intn(Height)
+		if Grid[Index(x, y)] == 0 {
+			spawn(x, y, 2)
+		} else {
+			i--
+		}
+	}
+
+	if RandomizeInitialTimers {
+		desyncTimers()
+	}
+}
+
+// / @brief Give every creature random initial timers instead of full ones.
+var RandomizeInitialTimers bool = false
+
+// / @brief Draw each creature's timers uniformly from [1, full value].
+// / @details Avoids the first-generation pulse of a population whose timers
+// / all start in sync. Runs after placement, so the creature positions for a
+// / given seed are the same as without it. Timers whose full value is 0
+// / stay 0.
+func desyncTimers() {
+	draw := func(full int) int {
+		if full <= 0 {
+			return full
+		}
+		return 1 + rnd.Intn(full)
+	}
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			i := Index(x, y)
+			p := paramsAt(x, y)
+			switch Grid[i] {
+			case 1:
+				BreedTimer[i] = draw(p.fishBreed)
+				StarveTimer[i] = draw(p.fishStarve)
+			case 2:
+				BreedTimer[i] = draw(p.sharkBreed)
+				StarveTimer[i] = draw(p.sharkStarve)
+			}
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package wator
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// / @brief Ticks at the default density (6% fish, 2.5% sharks), on the
+// / default 400x400 grid and on one whose planes are far beyond the caches.
+// / The world is rebuilt every 100 ticks so the population stays near its
+// / starting density.
+func BenchmarkUpdate(b *testing.B) {
+	for _, bc := range []struct{ side, threads int }{{400, 1}, {400, 4}, {1600, 1}} {
+		b.Run(fmt.Sprintf("%dx%d/threads=%d", bc.side, bc.side, bc.threads), func(b *testing.B) {
+			cells := bc.side * bc.side
+			w, err := NewWorld(WithSize(bc.side, bc.side), WithFish(cells/16), WithSharks(cells/40),
+				WithThreads(bc.threads), WithSeed(1))
+			if err != nil {
+				b.Fatal(err)
+			}
+			w.Load()
+			defer w.Unload()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if Tick == 100 {
+					b.StopTimer()
+					InitWorld()
+					b.StartTimer()
+				}
+				if err := Update(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// / @brief A grid one cell thin is valid with the default vision and runs.
+func TestThinGrid(t *testing.T) {
+	for _, size := range [][2]int{{1, 30}, {30, 1}, {1, 1}} {
+		w, err := NewWorld(WithSize(size[0], size[1]), WithFish(1), WithSharks(0), WithSeed(1))
+		if err != nil {
+			t.Fatalf("%dx%d: %v", size[0], size[1], err)
+		}
+		for i := 0; i < 10; i++ {
+			if err := w.Step(); err != nil {
+				t.Fatalf("%dx%d: tick %d: %v", size[0], size[1], w.Tick(), err)
+			}
+		}
+	}
+}
+
+// / @brief Over one tick, the share of fish that stay put matches
+// / `FishStayProb` and the share of hungry sharks that move matches
+// / `SharkMoveProb`.
+// / @details The creatures sit on a lattice three cells apart, so every one
+// / of them has free neighbors and no two can reach the same cell; a
+// / creature still on a lattice point after the tick stayed.
+func TestStayAndMoveRates(t *testing.T) {
+	const side, spacing = 300, 3
+	lattice := func(c byte) string {
+		var b strings.Builder
+		for y := 0; y < side; y++ {
+			for x := 0; x < side; x++ {
+				if x%spacing == 0 && y%spacing == 0 {
+					b.WriteByte(c)
+				} else {
+					b.WriteByte('.')
+				}
+			}
+			b.WriteByte('\n')
+		}
+		return b.String()
+	}
+	const n = (side / spacing) * (side / spacing)
+
+	for _, tc := range []struct {
+		name  string
+		cells string
+		p     float64
+		set   func(p float64)
+		moved bool // whether p is the share that moves rather than stays
+	}{
+		{"fish stay 0.3", lattice('f'), 0.3, func(p float64) { FishStayProb = p }, false},
+		{"fish stay 0.8", lattice('f'), 0.8, func(p float64) { FishStayProb = p }, false},
+		{"shark move 0.25", lattice('S'), 0.25, func(p float64) { SharkMoveProb = p }, true},
+		{"shark move 0.6", lattice('S'), 0.6, func(p float64) { SharkMoveProb = p }, true},
+	} {
+		w := newTestWorld(t, tc.cells, func() { NoFishBreed, NoSharkBreed = true, true; tc.set(tc.p) })
+		step(t, w, 1)
+		stayed := 0
+		ForEachCreature(func(x, y int, state uint8, breed, starve int) {
+			if x%spacing == 0 && y%spacing == 0 {
+				stayed++
+			}
+		})
+		rate := float64(stayed) / n
+		if tc.moved {
+			rate = 1 - rate
+		}
+		// five standard deviations of the binomial share
+		if tol := 5 * math.Sqrt(tc.p*(1-tc.p)/n); math.Abs(rate-tc.p) > tol {
+			t.Errorf("%s: measured %.4f, want %.2f +/- %.4f", tc.name, rate, tc.p, tol)
+		}
+	}
+}
+
+// / @brief With `FishStarve` set, fish that cannot breed die on the tick
+// / their starve timer runs out, and fish that breed in time live on.
+func TestFishStarveSchedule(t *testing.T) {
+	const starve = 4
+	layout := `
+f.......
+........
+....f...
+........
+........
+..f.....`
+	w := newTestWorld(t, layout, func() { FishStarve, NoFishBreed = starve, true })
+	for tick := 1; tick < starve; tick++ {
+		step(t, w, 1)
+		if n := w.Fish(); n != 3 {
+			t.Fatalf("tick %d: %d fish, want all 3 until tick %d", tick, n, starve)
+		}
+	}
+	step(t, w, 1)
+	if n := w.Fish(); n != 0 {
+		t.Errorf("tick %d: %d fish survived their starve timer", starve, n)
+	}
+
+	w = newTestWorld(t, layout, func() { FishStarve, FishBreed = starve, starve-1 })
+	step(t, w, 3*starve)
+	if n := w.Fish(); n <= 3 {
+		t.Errorf("fish breeding every %d ticks starved down to %d", starve-1, n)
+	}
+}
+
+// / @brief Creatures born with a breed jitter J get timers spread over all
+// / of [breed-J, breed+J], and none outside it.
+func TestBreedJitterRange(t *testing.T) {
+	const fishBreed, fishJitter = 6, 3
+	const sharkBreed, sharkJitter = 8, 2
+	w, err := NewWorld(WithSize(60, 40), WithFish(600), WithSharks(150), WithThreads(1), WithSeed(3),
+		WithFishBreed(fishBreed), WithSharkBreed(sharkBreed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Load()
+	defer w.Unload()
+	FishBreedJitter, SharkBreedJitter = fishJitter, sharkJitter
+	if err := ValidateConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := [3]map[int]int{nil, {}, {}}
+	OnBirth = func(x, y int, species uint8) {
+		// a newborn fish can be eaten later in its tick
+		if c := CellAt(x, y); c.State == species {
+			seen[species][c.Breed]++
+		}
+	}
+	defer func() { OnBirth = nil }()
+	step(t, w, 60)
+
+	for _, sp := range []struct {
+		name          string
+		species       uint8
+		breed, jitter int
+	}{{"fish", 1, fishBreed, fishJitter}, {"shark", 2, sharkBreed, sharkJitter}} {
+		for v := range seen[sp.species] {
+			if v < sp.breed-sp.jitter || v > sp.breed+sp.jitter {
+				t.Errorf("%s born with breed timer %d, outside %d +/- %d", sp.name, v, sp.breed, sp.jitter)
+			}
+		}
+		for v := sp.breed - sp.jitter; v <= sp.breed+sp.jitter; v++ {
+			if seen[sp.species][v] == 0 {
+				t.Errorf("no %s born with breed timer %d (seen %v)", sp.name, v, seen[sp.species])
+			}
+		}
+	}
+}
+
+// / @brief Initial timers start full by default; with
+// / `RandomizeInitialTimers` they spread over all of [1, full] for the same
+// / creature positions, and timers that are off stay 0.
+func TestInitialTimers(t *testing.T) {
+	const fishBreed, sharkBreed, sharkStarve = 6, 10, 5
+	build := func(random bool) (cells string, timers [3][2]map[int]int) {
+		w, err := NewWorld(WithSize(100, 100), WithFish(2000), WithSharks(1000), WithThreads(1), WithSeed(5),
+			WithFishBreed(fishBreed), WithSharkBreed(sharkBreed), WithSharkStarve(sharkStarve), WithFishStarve(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Do(func() {
+			RandomizeInitialTimers = random
+			InitWorld()
+			var b strings.Builder
+			WriteText(&b)
+			cells = b.String()
+			timers = [3][2]map[int]int{{}, {{}, {}}, {{}, {}}}
+			ForEachCreature(func(x, y int, state uint8, breed, starve int) {
+				timers[state][0][breed]++
+				timers[state][1][starve]++
+			})
+		})
+		return cells, timers
+	}
+
+	fixed, sync := build(false)
+	for _, c := range []struct {
+		name      string
+		got       map[int]int
+		want, num int
+	}{
+		{"fish breed", sync[1][0], fishBreed, 2000},
+		{"fish starve", sync[1][1], 0, 2000},
+		{"shark breed", sync[2][0], sharkBreed, 1000},
+		{"shark starve", sync[2][1], sharkStarve, 1000},
+	} {
+		if len(c.got) != 1 || c.got[c.want] != c.num {
+			t.Errorf("default %s timers %v, want all %d", c.name, c.got, c.want)
+		}
+	}
+
+	cells, spread := build(true)
+	if cells != fixed {
+		t.Error("randomized timers moved the creatures")
+	}
+	if len(spread[1][1]) != 1 || spread[1][1][0] != 2000 {
+		t.Errorf("fish starve timers %v with fish starving off, want all 0", spread[1][1])
+	}
+	for _, c := range []struct {
+		name      string
+		got       map[int]int
+		full, num int
+	}{
+		{"fish breed", spread[1][0], fishBreed, 2000},
+		{"shark breed", spread[2][0], sharkBreed, 1000},
+		{"shark starve", spread[2][1], sharkStarve, 1000},
+	} {
+		sum := 0
+		for v, n := range c.got {
+			if v < 1 || v > c.full {
+				t.Errorf("%s timer %d outside [1, %d]", c.name, v, c.full)
+			}
+			sum += v * n
+		}
+		for v := 1; v <= c.full; v++ {
+			// a uniform draw puts num/full creatures on each value
+			if n := c.got[v]; n < c.num/c.full/2 {
+				t.Errorf("%s timer %d drawn %d times of %d, want about %d", c.name, v, n, c.num, c.num/c.full)
+			}
+		}
+		if mean, want := float64(sum)/float64(c.num), float64(c.full+1)/2; math.Abs(mean-want) > 0.1*want {
+			t.Errorf("%s timers average %.2f, want about %.2f", c.name, mean, want)
+		}
+	}
+}
+
+// / @brief Fish in a fast-breed zone breed from the first tick, while those
+// / outside wait for the global breed time.
+func TestZoneBreedsSooner(t *testing.T) {
+	const global = 5
+	fast := 1
+	w := newTestWorld(t, `
+....................
+....................
+.....f.........f....
+....................
+....................`, func() {
+		FishBreed = global
+		if err := SetZones([]ZoneSpec{{Name: "nursery", W: 10, H: 5, FishBreed: &fast}}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	var inside, outside []int // ticks of the births in and out of the zone
+	OnBirth = func(x, y int, species uint8) {
+		if x < 10 {
+			inside = append(inside, Tick)
+		} else {
+			outside = append(outside, Tick)
+		}
+	}
+	defer func() { OnBirth = nil }()
+	// in 4 ticks neither fish can leave its half
+	step(t, w, 4)
+	if len(inside) < 2 || inside[0] > 2 {
+		t.Errorf("births in the zone at ticks %v, want several from tick 1 or 2", inside)
+	}
+	if len(outside) != 0 {
+		t.Errorf("births outside the zone at ticks %v, before the global breed time of %d", outside, global)
+	}
+	step(t, w, 2)
+	if len(outside) == 0 {
+		t.Errorf("no births outside the zone by tick %d", Tick)
+	}
+}
+
+// / @brief A species that cannot breed never grows, alone or with the other
+// / one breeding, and with both off nothing is ever born.
+func TestNoBreedNeverGrows(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		noFish, noSha bool
+	}{
+		{"no fish breed", true, false},
+		{"no shark breed", false, true},
+		{"neither", true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := NewWorld(WithSize(50, 40), WithFish(400), WithSharks(100), WithThreads(2), WithSeed(3),
+				WithFishBreed(1), WithSharkBreed(1), WithSharkStarve(6), WithFishStarve(8))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Load()
+			defer w.Unload()
+			NoFishBreed, NoSharkBreed = tc.noFish, tc.noSha
+			births := 0
+			OnBirth = func(x, y int, species uint8) {
+				if (species == 1 && tc.noFish) || (species == 2 && tc.noSha) {
+					births++
+				}
+			}
+			defer func() { OnBirth = nil }()
+
+			fish, sharks := CountFish(), CountSharks()
+			for i := 0; i < 100 && !Extinct; i++ {
+				if err := w.Step(); err != nil {
+					t.Fatal(err)
+				}
+				f, s := CountFish(), CountSharks()
+				if (tc.noFish && f > fish) || (tc.noSha && s > sharks) {
+					t.Fatalf("tick %d: fish %d -> %d, sharks %d -> %d", Tick, fish, f, sharks, s)
+				}
+				fish, sharks = f, s
+			}
+			if births != 0 {
+				t.Errorf("%d births of a species that cannot breed", births)
+			}
+		})
+	}
+}
@@ -0,0 +1,415 @@
+package wator
+
+/// @file world.go
+/// @brief `World`: a self-contained simulation for programs embedding the engine.
+/// @details The engine functions work on package-level state (`Grid`, the
+/// timer planes, `Tick`, the parameters, the random source, ...). A `World`
+/// owns a complete copy of that state and, for the duration of each method
+/// call, swaps it in under a package-wide lock. Everything a tick reads or
+/// writes is part of the copy: cells, timers and tick, every setting
+/// (breed/starve times, `Topology`, `Scheduler`, `SharkVision`, zones, ...),
+/// the random source, the event callbacks, summary counting and the
+/// fingerprint, worklist and scratch buffers. Only the worker pool
+/// (pool.go) is shared. Worlds therefore never see each other's state and
+/// each one repeats its own run from its own seed, but they are not
+/// parallel: calls from several goroutines are safe and run one at a time.
+/// Stepping worlds concurrently would need the engine functions to become
+/// methods, which this package does not do.
+///
+/// `NewWorld()` takes `Option`s for the size, seed, breed and starve times,
+/// initial populations and thread count; every other setting starts out
+/// as the package value and can be changed for the world alone through
+/// `Do()`, e.g. `w.Do(func() { SetTopology(TopoHex) })`. A new world has
+/// no event callbacks and does not count occupancy until `Do()` sets them
+/// up.
+///
+/// `Load()` makes a world the package state itself until `Unload()`, for a
+/// front end that drives one world through the package functions and
+/// steps others with their methods (compare mode). Otherwise the
+/// package-level state must not be used directly while a `World` method
+/// runs.
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// / @brief Serializes all `World` calls, since they share the package state.
+var worldLock sync.Mutex
+
+// / @brief The world made the package state with `Load()`, nil if none.
+var loadedWorld *World
+
+// / @brief One simulated ocean with its own cells, timers and parameters.
+// / @details Every field mirrors the package variable it is swapped with.
+type World struct {
+	// the ocean
+	cells, stack, nextCells, nextStack   []uint8
+	breed, starve, nextBreed, nextStarve []int
+	tick, width, height                  int
+	extinct                              bool
+
+	// parameters
+	fishBreed, sharkBreed, sharkStarve, fishStarve int
+	numFish, numShark, threads                     int
+	fishStayProb, sharkMoveProb                    float64
+	noFishBreed, noSharkBreed                      bool
+	fishBreedJitter, sharkBreedJitter              int
+	deterministic, randomTimers                    bool
+	reseedEvery, reseedFish, reseedSharks          int
+	sharkVision, maxStack                          int
+	stacking, clusterMetric, tileTiming            bool
+	topology, sharkHunt, onExtinct, tileOrder      string
+	scheduler, engine                              string
+	tileSplit                                      int
+	serialTiles, sparse                            bool
+	zones                                          []ZoneSpec
+	zoneIndex                                      []uint8
+
+	// random source
+	seed      int64 // for NewWorld() to seed `source` with
+	rngSource string
+	source    *countedSource
+	rnd       *rand.Rand
+	tileRands []*rand.Rand
+
+	// callbacks, counters and the fingerprint
+	onBirth, onDeath, onEat func(x, y int, species uint8)
+	occupancy               []uint32
+	trackFP                 bool
+	fpCheck                 int
+	cellFP                  []uint64
+	tileFP                  []uint64
+	rollingFP               uint64
+	rollingValid            bool
+
+	// buffers reused from tick to tick
+	claims        []uint32
+	tileEvents    [][]cellEvent
+	orderBuf      [][]int32
+	outbox        [][]int32
+	outboxStep    []func(x, y int)
+	tileJobs      []tileJob
+	occupied      int
+	tileFilled    []int
+	work          [][]int32
+	nextWork      [][]int32
+	workLayout    [4]int
+	workValid     bool
+	visionGrid    []uint8
+	tileTimes     []time.Duration
+	tilesLaunched int
+}
+
+// / @brief A setting for `NewWorld()`; defaults are the package-level values.
+type Option func(w *World)
+
+// / @brief Ticks before a fish breeds (`FishBreed`).
+func WithFishBreed(n int) Option {
+	return func(w *World) { w.fishBreed = n }
+}
+
+// / @brief Ticks before a shark breeds (`SharkBreed`).
+func WithSharkBreed(n int) Option {
+	return func(w *World) { w.sharkBreed = n }
+}
+
+// / @brief Ticks a shark survives without eating (`SharkStarve`).
+func WithSharkStarve(n int) Option {
+	return func(w *World) { w.sharkStarve = n }
+}
+
+// / @brief Ticks a fish survives without breeding, 0 = never (`FishStarve`).
+func WithFishStarve(n int) Option {
+	return func(w *World) { w.fishStarve = n }
+}
+
+// / @brief Number of fish placed by `Reset()` (`NumFish`).
+func WithFish(n int) Option {
+	return func(w *World) { w.numFish = n }
+}
+
+// / @brief Number of sharks placed by `Reset()` (`NumShark`).
+func WithSharks(n int) Option {
+	return func(w *World) { w.numShark = n }
+}
+
+// / @brief Worker goroutines used by `Step()` (`Threads`).
+func WithThreads(n int) Option {
+	return func(w *World) { w.threads = n }
+}
+
+// / @brief Grid dimensions (`Width`, `Height`).
+func WithSize(width, height int) Option {
+	return func(w *World) { w.width, w.height = width, height }
+}
+
+// / @brief Seed of the world's random source (see `Seed()`); without it the
+// / source is seeded from the clock.
+func WithSeed(seed int64) Option {
+	return func(w *World) { w.seed = seed }
+}
+
+// / @brief Exchange two values.
+func xchg[T any](a, b *T) {
+	*a, *b = *b, *a
+}
+
+// / @brief Exchange the world's state with the package state.
+func (w *World) swap() {
+	xchg(&Grid, &w.cells)
+	xchg(&StackCount, &w.stack)
+	xchg(&BreedTimer, &w.breed)
+	xchg(&StarveTimer, &w.starve)
+	xchg(&buffer, &w.nextCells)
+	xchg(&bufferStack, &w.nextStack)
+	xchg(&bufferBreed, &w.nextBreed)
+	xchg(&bufferStarve, &w.nextStarve)
+	xchg(&Tick, &w.tick)
+	xchg(&Width, &w.width)
+	xchg(&Height, &w.height)
+	xchg(&Extinct, &w.extinct)
+
+	xchg(&FishBreed, &w.fishBreed)
+	xchg(&SharkBreed, &w.sharkBreed)
+	xchg(&SharkStarve, &w.sharkStarve)
+	xchg(&FishStarve, &w.fishStarve)
+	xchg(&NumFish, &w.numFish)
+	xchg(&NumShark, &w.numShark)
+	xchg(&Threads, &w.threads)
+	xchg(&FishStayProb, &w.fishStayProb)
+	xchg(&SharkMoveProb, &w.sharkMoveProb)
+	xchg(&NoFishBreed, &w.noFishBreed)
+	xchg(&NoSharkBreed, &w.noSharkBreed)
+	xchg(&FishBreedJitter, &w.fishBreedJitter)
+	xchg(&SharkBreedJitter, &w.sharkBreedJitter)
+	xchg(&Deterministic, &w.deterministic)
+	xchg(&RandomizeInitialTimers, &w.randomTimers)
+	xchg(&ReseedEvery, &w.reseedEvery)
+	xchg(&ReseedFish, &w.reseedFish)
+	xchg(&ReseedSharks, &w.reseedSharks)
+	xchg(&SharkVision, &w.sharkVision)
+	xchg(&MaxStack, &w.maxStack)
+	xchg(&Stacking, &w.stacking)
+	xchg(&ClusterMetric, &w.clusterMetric)
+	xchg(&TileTiming, &w.tileTiming)
+	xchg(&Topology, &w.topology)
+	xchg(&SharkHunt, &w.sharkHunt)
+	xchg(&onExtinct, &w.onExtinct)
+	xchg(&tileOrder, &w.tileOrder)
+	xchg(&Scheduler, &w.scheduler)
+	xchg(&Engine, &w.engine)
+	xchg(&TileSplit, &w.tileSplit)
+	xchg(&SerialTiles, &w.serialTiles)
+	xchg(&Sparse, &w.sparse)
+	xchg(&Zones, &w.zones)
+	xchg(&zoneIndex, &w.zoneIndex)
+
+	xchg(&rngSource, &w.rngSource)
+	xchg(&source, &w.source)
+	xchg(&rnd, &w.rnd)
+	xchg(&tileRands, &w.tileRands)
+
+	xchg(&OnBirth, &w.onBirth)
+	xchg(&OnDeath, &w.onDeath)
+	xchg(&OnEat, &w.onEat)
+	xchg(&occupancy, &w.occupancy)
+	xchg(&TrackFingerprint, &w.trackFP)
+	xchg(&FingerprintCheck, &w.fpCheck)
+	xchg(&cellFP, &w.cellFP)
+	xchg(&tileFP, &w.tileFP)
+	xchg(&rollingFP, &w.rollingFP)
+	xchg(&rollingValid, &w.rollingValid)
+
+	xchg(&claims, &w.claims)
+	xchg(&tileEvents, &w.tileEvents)
+	xchg(&orderBuf, &w.orderBuf)
+	xchg(&outbox, &w.outbox)
+	xchg(&outboxStep, &w.outboxStep)
+	xchg(&tileJobs, &w.tileJobs)
+	xchg(&occupied, &w.occupied)
+	xchg(&tileFilled, &w.tileFilled)
+	xchg(&work, &w.work)
+	xchg(&nextWork, &w.nextWork)
+	xchg(&workLayout, &w.workLayout)
+	xchg(&workValid, &w.workValid)
+	xchg(&visionGrid, &w.visionGrid)
+	xchg(&tileTimes, &w.tileTimes)
+	xchg(&tilesLaunched, &w.tilesLaunched)
+}
+
+// / @brief Run `f` with the world loaded into the package state.
+func (w *World) with(f func()) {
+	worldLock.Lock()
+	defer worldLock.Unlock()
+	if loadedWorld == w {
+		f()
+		return
+	}
+	w.swap()
+	defer w.swap()
+	f()
+}
+
+// / @brief Create a world populated by `InitWorld()`.
+// / @details Settings not given as options are copied from the current
+// / package-level values, zones are laid out anew for the world's size. The
+// / combination is checked with `ValidateConfig()`.
+// / @return *World The new world at tick 0.
+// / @return error Non-nil for an invalid setting; no world is created then.
+func NewWorld(opts ...Option) (*World, error) {
+	worldLock.Lock()
+	w := &World{
+		width: Width, height: Height,
+		fishBreed: FishBreed, sharkBreed: SharkBreed, sharkStarve: SharkStarve, fishStarve: FishStarve,
+		numFish: NumFish, numShark: NumShark, threads: Threads,
+		fishStayProb: FishStayProb, sharkMoveProb: SharkMoveProb,
+		noFishBreed: NoFishBreed, noSharkBreed: NoSharkBreed,
+		fishBreedJitter: FishBreedJitter, sharkBreedJitter: SharkBreedJitter,
+		deterministic: Deterministic, randomTimers: RandomizeInitialTimers,
+		reseedEvery: ReseedEvery, reseedFish: ReseedFish, reseedSharks: ReseedSharks,
+		sharkVision: SharkVision, maxStack: MaxStack,
+		stacking: Stacking, clusterMetric: ClusterMetric, tileTiming: TileTiming,
+		topology: Topology, sharkHunt: SharkHunt, onExtinct: onExtinct, tileOrder: tileOrder,
+		scheduler: Scheduler, engine: Engine, tileSplit: TileSplit,
+		serialTiles: SerialTiles, sparse: Sparse,
+		rngSource: rngSource, trackFP: TrackFingerprint, fpCheck: FingerprintCheck,
+		occupied: -1, seed: time.Now().UnixNano(),
+	}
+	zones := Zones
+	worldLock.Unlock()
+
+	for _, opt := range opts {
+		opt(w)
+	}
+	// the source picks its generator from the world's -rng when seeded
+	w.source = &countedSource{}
+	w.rnd = rand.New(w.source)
+	w.with(func() { Seed(w.seed) })
+	if err := checkSize(w.width, w.height); err != nil {
+		return nil, err
+	}
+	w.cells, w.nextCells = newPlane[uint8](w.width, w.height), newPlane[uint8](w.width, w.height)
+	w.stack, w.nextStack = newPlane[uint8](w.width, w.height), newPlane[uint8](w.width, w.height)
+	w.breed, w.nextBreed = newPlane[int](w.width, w.height), newPlane[int](w.width, w.height)
+	w.starve, w.nextStarve = newPlane[int](w.width, w.height), newPlane[int](w.width, w.height)
+	var err error
+	w.with(func() {
+		if err = ValidateConfig(); err == nil {
+			err = SetZones(zones)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.Reset()
+	return w, nil
+}
+
+// / @brief Make the world the package state until `Unload()`.
+// / @details The package functions and variables then work on this world,
+// / and its own methods skip the swap. A world loaded before is unloaded
+// / first.
+func (w *World) Load() {
+	worldLock.Lock()
+	defer worldLock.Unlock()
+	if loadedWorld == w {
+		return
+	}
+	if loadedWorld != nil {
+		loadedWorld.swap()
+	}
+	w.swap()
+	loadedWorld = w
+}
+
+// / @brief Put back the package state `Load()` replaced.
+func (w *World) Unload() {
+	worldLock.Lock()
+	defer worldLock.Unlock()
+	if loadedWorld == w {
+		w.swap()
+		loadedWorld = nil
+	}
+}
+
+// / @brief Repopulate the world at random and restart it at tick 0.
+func (w *World) Reset() {
+	w.with(func() {
+		InitWorld()
+		Extinct = false
+	})
+}
+
+// / @brief Advance the world by one tick, unless it is already extinct.
+// / @return error An error from `Update()`.
+func (w *World) Step() error {
+	var err error
+	w.with(func() {
+		if Extinct {
+			return
+		}
+		err = Update()
+		Extinct = CheckExtinction()
+	})
+	return err
+}
+
+// / @brief Number of ticks simulated since the world was (re)populated.
+func (w *World) Tick() int {
+	var n int
+	w.with(func() { n = Tick })
+	return n
+}
+
+// / @brief Whether the world died out (see `SetOnExtinct()`).
+func (w *World) Extinct() bool {
+	var e bool
+	w.with(func() { e = Extinct })
+	return e
+}
+
+// / @brief Current number of fish.
+func (w *World) Fish() int {
+	var n int
+	w.with(func() { n = CountFish() })
+	return n
+}
+
+// / @brief Current number of sharks.
+func (w *World) Sharks() int {
+	var n int
+	w.with(func() { n = CountSharks() })
+	return n
+}
+
+// / @brief Inspect one cell; coordinates wrap like in `CellAt()`.
+func (w *World) Cell(x, y int) CellInfo {
+	var c CellInfo
+	w.with(func() { c = CellAt(x, y) })
+	return c
+}
+
+// / @brief Put a creature into one cell or empty it, see `SetCell()`.
+func (w *World) SetCell(x, y int, state uint8) error {
+	var err error
+	w.with(func() { err = SetCell(x, y, state) })
+	return err
+}
+
+// / @brief The grid in the text form of `WriteText()`.
+func (w *World) String() string {
+	var b strings.Builder
+	w.with(func() { WriteText(&b) })
+	return b.String()
+}
+
+// / @brief Run `f` with the world loaded into the package state.
+// / @details For everything without a `World` method, e.g. `WriteBinary()`,
+// / `ForEachCreature()` or a setter such as `SetTopology()` that should
+// / apply to this world only. `f` must not call methods of any `World`.
+func (w *World) Do(f func()) {
+	w.with(f)
+}
@@ -0,0 +1,168 @@
+package wator
+
+import (
+	"strings"
+	"testing"
+)
+
+// / @brief A world holding exactly `cells`, in the text form of
+// / `WriteText()`, loaded as the package state until the test ends.
+// / @details The world is single-threaded and seeded with 1. `cfg`, if not
+// / nil, runs in the loaded world before the creatures are placed, so the
+// / timers they get follow the settings it makes. The settings it may set
+// / are all part of the world (see `Load()`), so they end with this world
+// / and never reach another test's.
+func newTestWorld(t testing.TB, cells string, cfg func()) *World {
+	t.Helper()
+	// start from the package settings, not those of a world loaded before
+	if loadedWorld != nil {
+		loadedWorld.Unload()
+	}
+	cells = strings.TrimSpace(cells)
+	lines := strings.Split(cells, "\n")
+	w, err := NewWorld(WithSize(len(lines[0]), len(lines)), WithFish(0), WithSharks(0),
+		WithThreads(1), WithSeed(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Load()
+	t.Cleanup(w.Unload)
+	if cfg != nil {
+		cfg()
+	}
+	if err := ValidateConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadText(strings.NewReader(cells)); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// / @brief Run `n` ticks of the loaded world.
+func step(t testing.TB, w *World, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := w.Step(); err != nil {
+			t.Fatalf("tick %d: %v", w.Tick(), err)
+		}
+	}
+}
+
+// / @brief A shark next to its only fish eats it and is fed again.
+func TestSharkEatsNeighbor(t *testing.T) {
+	w := newTestWorld(t, `
+...
+.Sf
+...`, func() { SharkStarve = 5 })
+	StarveTimer[Index(1, 1)] = 2
+
+	step(t, w, 1)
+	if f, s := w.Fish(), w.Sharks(); f != 0 || s != 1 {
+		t.Fatalf("after one tick: %d fish, %d sharks, want 0 and 1", f, s)
+	}
+	if c := w.Cell(2, 1); c.State != 2 || c.Starve != SharkStarve {
+		t.Errorf("fish's cell holds %+v, want a shark with starve timer %d", c, SharkStarve)
+	}
+}
+
+// / @brief A fish and a shark moving into the same empty cell leave a fed
+// / shark there, whichever of them is scanned first (see `resolveConflict`).
+// / @details With the fixed N, E, S, W order each takes its first empty
+// / neighbor. Scanned first, the fish goes east into (1,0), its north
+// / being the last row, which stays blocked until that row's turn. Scanned
+// / first, the lower shark finds north taken at the start of the tick and
+// / goes east into (2,2), north of the fish.
+func TestContestedCell(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		layout       string
+		cx, cy       int
+		fish, sharks int
+	}{
+		{"fish first", `
+f....
+.S...
+.....
+.....
+f....`, 1, 0, 1, 1},
+		{"shark first", `
+.....
+.S...
+.S...
+..f..
+.....`, 2, 2, 0, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorld(t, tc.layout, func() {
+				Deterministic, NoFishBreed, NoSharkBreed, SharkStarve = true, true, true, 9
+			})
+			var meals [][2]int
+			OnEat = func(x, y int, species uint8) { meals = append(meals, [2]int{x, y}) }
+			defer func() { OnEat = nil }()
+
+			step(t, w, 1)
+			if len(meals) != 1 || meals[0] != [2]int{tc.cx, tc.cy} {
+				t.Fatalf("meals at %v, want one at (%d,%d)", meals, tc.cx, tc.cy)
+			}
+			if c := w.Cell(tc.cx, tc.cy); c.State != 2 || c.Starve != SharkStarve {
+				t.Errorf("contested cell holds %+v, want a shark with starve timer %d", c, SharkStarve)
+			}
+			if f, s := w.Fish(), w.Sharks(); f != tc.fish || s != tc.sharks {
+				t.Errorf("%d fish and %d sharks, want %d and %d", f, s, tc.fish, tc.sharks)
+			}
+		})
+	}
+}
+
+// / @brief Fish without an empty neighbor neither move nor breed, and keep
+// / their expired breed timers. Every neighbor of a fish in a full ocean is
+// / boxed in as well; next to one that can move away, a fish processed
+// / later may take the cell it left.
+func TestBoxedInFish(t *testing.T) {
+	w := newTestWorld(t, `
+fff
+fff
+fff`, func() { FishBreed = 1 })
+
+	step(t, w, 3)
+	if n := w.Fish(); n != 9 {
+		t.Fatalf("full ocean holds %d fish, want 9", n)
+	}
+	ForEachCreature(func(x, y int, state uint8, breed, starve int) {
+		if breed != 0 {
+			t.Errorf("fish at (%d, %d) has breed timer %d, want 0", x, y, breed)
+		}
+	})
+}
+
+// / @brief A shark boxed in by sharks ages by one starve tick per tick, waits
+// / at a breed timer of 0 without breeding in place, and starves on the tick
+// / its starve timer runs out.
+func TestBoxedInShark(t *testing.T) {
+	const breed, starve = 2, 5
+	w := newTestWorld(t, `
+SSS
+SSS
+SSS`, func() { SharkBreed, SharkStarve = breed, starve })
+	start := w.Cell(1, 1)
+	if start.Breed >= start.Starve-1 {
+		t.Fatalf("center starts at %+v; its breed timer must expire before it starves", start)
+	}
+
+	for tick := 1; tick < start.Starve; tick++ {
+		step(t, w, 1)
+		if n := w.Sharks(); n != 9 {
+			t.Fatalf("tick %d: %d sharks, want 9 without breeding or starving", tick, n)
+		}
+		c := w.Cell(1, 1)
+		want := CellInfo{State: 2, Breed: max(start.Breed-tick, 0), Starve: start.Starve - tick}
+		if c.State != want.State || c.Breed != want.Breed || c.Starve != want.Starve {
+			t.Fatalf("tick %d: center is %+v, want %+v", tick, c, want)
+		}
+	}
+	step(t, w, 1)
+	if c := w.Cell(1, 1); c.State != 0 {
+		t.Errorf("boxed-in shark still alive after its %d starve ticks: %+v", start.Starve, c)
+	}
+}
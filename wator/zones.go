@@ -0,0 +1,111 @@
+package wator
+
+/// @file zones.go
+/// @brief Rectangular regions of the ocean with their own parameters.
+/// @details `SetZones()` installs a list of zones (`-zones file.json` reads
+/// one from a file), each a rectangle plus overrides for some of the
+/// breed/starve parameters, e.g.
+///
+///	[{"name": "nursery", "x": 0, "y": 0, "w": 100, "h": 100, "fish_breed": 1}]
+///
+/// A creature uses the parameters of the zone it stands in when it ages,
+/// eats or breeds, and so do the timers of its offspring. Fields a zone
+/// leaves out follow the global flags (and PUT /config). Where zones
+/// overlap, the later one in the list wins. Without `-zones` the whole grid
+/// is a single global zone.
+///
+/// The zone of every cell is precomputed into `zoneIndex`, so `Update()`
+/// looks it up per creature instead of searching the list.
+
+import "fmt"
+
+// / @brief Largest number of zones; `zoneIndex` stores them in a byte.
+const maxZones = 255
+
+// / @brief One zone as read from the JSON file.
+type ZoneSpec struct {
+	Name        string `json:"name"`
+	X           int    `json:"x"`
+	Y           int    `json:"y"`
+	W           int    `json:"w"`
+	H           int    `json:"h"`
+	FishBreed   *int   `json:"fish_breed"`
+	SharkBreed  *int   `json:"shark_breed"`
+	SharkStarve *int   `json:"shark_starve"`
+	FishStarve  *int   `json:"fish_starve"`
+}
+
+// / @brief Breed/starve parameters in effect at one cell.
+type zoneParams struct {
+	fishBreed, sharkBreed, sharkStarve, fishStarve int
+}
+
+var Zones []ZoneSpec
+
+// / @brief Zone of every cell: 0 for the global zone, i+1 for `Zones[i]`.
+// / @details Nil without `-zones`.
+var zoneIndex []uint8
+
+// / @brief Validate `list` and rebuild `zoneIndex` from it.
+// / @return error Non-nil if a zone lies outside the grid, is empty or has
+// / a negative override; the previous zones are kept then.
+func SetZones(list []ZoneSpec) error {
+	if len(list) > maxZones {
+		return fmt.Errorf("at most %d zones are supported, got %d", maxZones, len(list))
+	}
+	for i, z := range list {
+		if z.W <= 0 || z.H <= 0 || z.X < 0 || z.Y < 0 || z.X+z.W > Width || z.Y+z.H > Height {
+			return fmt.Errorf("zone %d (%q): rectangle %d,%d %dx%d does not fit the %dx%d grid",
+				i, z.Name, z.X, z.Y, z.W, z.H, Width, Height)
+		}
+		for _, v := range []*int{z.FishBreed, z.SharkBreed, z.SharkStarve, z.FishStarve} {
+			if v != nil && *v < 0 {
+				return fmt.Errorf("zone %d (%q): timers must not be negative", i, z.Name)
+			}
+		}
+	}
+
+	Zones = list
+	if len(list) == 0 {
+		zoneIndex = nil
+		return nil
+	}
+	idx := NewPlane[uint8]()
+	for i, z := range list {
+		for y := z.Y; y < z.Y+z.H; y++ {
+			for x := z.X; x < z.X+z.W; x++ {
+				idx[Index(x, y)] = uint8(i + 1)
+			}
+		}
+	}
+	zoneIndex = idx
+	return nil
+}
+
+// / @brief Parameters in effect at cell (x, y).
+// / @details The global parameters with the overrides of the cell's zone
+// / applied, so runtime changes to the globals are picked up immediately.
+func paramsAt(x, y int) zoneParams {
+	p := zoneParams{FishBreed, SharkBreed, SharkStarve, FishStarve}
+	if zoneIndex == nil {
+		return p
+	}
+	i := zoneIndex[Index(x, y)]
+	if i == 0 {
+		return p
+	}
+	z := &Zones[i-1]
+	if z.FishBreed != nil {
+		p.fishBreed = *z.FishBreed
+	}
+	if z.SharkBreed != nil {
+		p.sharkBreed = *z.SharkBreed
+	}
+	if z.SharkStarve != nil {
+		p.sharkStarve = *z.SharkStarve
+	}
+	if z.FishStarve != nil {
+		p.fishStarve = *z.FishStarve
+	}
+	return p
+}
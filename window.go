@@ -0,0 +1,268 @@
+//go:build !headless
+
+package main
+
+/// @file window.go
+/// @brief The Ebiten window: drawing the world and the per-frame handler.
+/// @details Left out of binaries built with `-tags headless` (see
+/// window_headless.go), which then do not link Ebiten or any graphics
+/// library at all.
+
+import (
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// / @brief Whether this binary can open a window.
+const haveWindow = true
+
+// / @brief Ticks owed to `simTPS` but not run yet, carried between calls of
+// / `frame()` in synchronous mode.
+var tickDue float64 = 0
+
+// / @brief Offscreen image holding the grid at 1:1 (times `scale`).
+var worldImg *ebiten.Image
+
+// / @brief CPU-side pixels of `worldImg`, filled by `renderTo()` or dirty.go.
+var worldPix *image.RGBA
+
+// / @brief Render a grid into the provided Ebiten image.
+// / @details The cells that changed since the last frame are redrawn in
+// / `worldPix` and uploaded into `worldImg` (dirty.go; the whole grid goes
+// / through the pure `renderTo()` when fading or with the tile overlay),
+// / which is then copied into `window` through the zoom/pan transform from
+// / view.go.
+// / @param window Pointer to the Ebiten image used as the drawing surface.
+// / @param cells The grid to draw: `wator.Grid` itself, or a published snapshot
+// / when the simulation runs in the background.
+func display(window *ebiten.Image, cells []uint8) {
+	if worldImg == nil {
+		worldImg = ebiten.NewImage(wator.Width*scale, wator.Height*scale)
+		worldPix = newGridImage()
+	}
+	worldDirty.present(worldImg, &worldFade, worldPix, cells)
+
+	drawTiles(worldImg)
+
+	window.Fill(pal.bg)
+	drawWorld(window, worldImg, 0)
+	drawLetterbox(window)
+	drawTileLegend(window)
+	drawTune(window)
+	drawControls(window)
+}
+
+// / @brief Per-tick half of Ebiten's run loop (`Update` of the game).
+// / @details Applies zoom/pan and overlay input and calls `wator.Update()`
+// / as many times as `ticksDue()` says, and not at all once the grid is
+// / extinct. Ebiten calls it 60 times per second however fast the
+// / simulation runs, so input stays responsive at one tick every few
+// / seconds as well as at hundreds of ticks per frame; `drawFrame()` draws.
+// / With `async` set the simulation runs in sim.go's goroutine instead and
+// / this only handles input and returns the error that stopped it.
+// / @return error Propagates any error coming from `wator.Update()`.
+func frame() error {
+	updateView()
+	updateOverlay()
+	updateFade()
+	updateTune()
+	updateControls()
+	updateGIFKey()
+	updateSeed()
+
+	worldMu.Lock()
+	defer worldMu.Unlock()
+
+	if async {
+		return simErr
+	}
+
+	if compareMode {
+		for n := ticksDue(); n > 0; n-- {
+			if err := stepBoth(nil, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	updateHistoryKeys()
+
+	if wator.Extinct {
+		return nil
+	}
+	for n := ticksDue(); n > 0 && !wator.Extinct; n-- {
+		pushHistory()
+		err := wator.Update()
+		recordTick()
+		wator.Extinct = wator.CheckExtinction()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// / @brief Number of ticks to run in this call of `frame()`.
+// / @details Adds this call's share of `simTPS` to `tickDue` and takes the
+// / whole ticks out of it: at 60 one per call, at 6 one every tenth call,
+// / at 6000 a hundred per call. While paused only a Step runs, and nothing
+// / is carried over to after resuming. The caller holds `worldMu`.
+func ticksDue() int {
+	if paused {
+		tickDue = 0
+		if runTick() {
+			return 1
+		}
+		return 0
+	}
+	rate := simTPS
+	if rate <= 0 {
+		rate = 60
+	}
+	tickDue += rate / float64(ebiten.TPS())
+	// the shares add up with rounding errors; don't lose the tick to one
+	n := int(tickDue + 1e-9)
+	tickDue -= float64(n)
+	return n
+}
+
+// / @brief Per-frame half of Ebiten's run loop (`Draw` of the game).
+// / @details Draws the world via `display`: the latest published snapshot
+// / with `async`, otherwise the loaded world, next to world B in compare
+// / mode. A GIF recorded per frame rather than per tick is captured here.
+// / @param window Pointer to the Ebiten image for the frame.
+func drawFrame(window *ebiten.Image) {
+	if async {
+		snap := latestSnapshot()
+		display(window, snap.cells)
+		if !gifPerTick {
+			captureGIF(snap.cells)
+		}
+		if snap.extinct {
+			drawExtinct(window)
+		}
+		return
+	}
+
+	worldMu.Lock()
+	defer worldMu.Unlock()
+
+	if compareMode {
+		drawCompare(window)
+		return
+	}
+	display(window, wator.Grid)
+	if !gifPerTick {
+		captureGIF(wator.Grid)
+	}
+	if wator.Extinct {
+		drawExtinct(window)
+	}
+}
+
+// / @brief World B of `-compare` as drawn last, and its pixels.
+var cmpImg *ebiten.Image
+var cmpPix *image.RGBA
+
+// / @brief Draw both worlds of compare mode; the caller holds `worldMu`.
+// / @details World A is loaded; B's cells are presented with B loaded.
+func drawCompare(window *ebiten.Image) {
+	if cmpImg == nil {
+		cmpImg = ebiten.NewImage(wator.Width*scale, wator.Height*scale)
+		cmpPix = newGridImage()
+	}
+	worldB.Do(func() { cmpDirty.present(cmpImg, &cmpFade, cmpPix, wator.Grid) })
+
+	display(window, wator.Grid)
+	drawWorld(window, cmpImg, float64(wator.Width*scale))
+	drawLetterbox(window)
+
+	ax, ay := contentToWindow(0, 0)
+	bx, by := contentToWindow(wator.Width*scale, 0)
+	ebitenutil.DebugPrintAt(window, "A", ax+4, ay+4)
+	ebitenutil.DebugPrintAt(window, "B", bx+4, by+4)
+	if wator.Extinct {
+		drawExtinct(window)
+	}
+	if worldB.Extinct() {
+		x, y := contentToWindow(wator.Width*scale+wator.Width*scale/2, wator.Height*scale/2)
+		ebitenutil.DebugPrintAt(window, "extinct", x-21, y-8)
+	}
+}
+
+// / @brief Handle the history keys.
+func updateHistoryKeys() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && stepBack() {
+		paused = true
+		wator.Extinct = false
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		paused = false
+	}
+}
+
+// / @brief `G` starts a GIF recording to `wator-<tick>.gif`, or stops the
+// / running one and writes it out.
+func updateGIFKey() {
+	if compareMode || !inpututil.IsKeyJustPressed(ebiten.KeyG) {
+		return
+	}
+	worldMu.Lock()
+	defer worldMu.Unlock()
+	if gifAnim != nil {
+		path := gifPath
+		if err := closeGIF(); err != nil {
+			controlMsg = err.Error()
+		} else {
+			controlMsg = "saved " + path
+		}
+	} else {
+		startGIF(fmt.Sprintf("wator-%06d.gif", wator.Tick))
+		controlMsg = "recording " + gifPath
+	}
+	fmt.Fprintln(os.Stderr, controlMsg)
+}
+
+// / @brief Seed shown in the window title.
+var titleSeed int64
+
+// / @brief Keep the title on the current seed; `C` copies the command line
+// / that reproduces the run (see repro.go) to the clipboard.
+// / @details The command line is printed to stderr as well, so it is not
+// / lost where no clipboard is available.
+func updateSeed() {
+	worldMu.Lock()
+	defer worldMu.Unlock()
+	if runSeed != titleSeed {
+		titleSeed = runSeed
+		ebiten.SetWindowTitle(fmt.Sprintf("Wa-Tor - seed %d", runSeed))
+	}
+	if !inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		return
+	}
+	cmd := reproCommand()
+	fmt.Fprintln(os.Stderr, cmd)
+	if err := copyToClipboard(cmd); err != nil {
+		controlMsg = err.Error()
+		return
+	}
+	controlMsg = fmt.Sprintf("copied seed %d and settings", runSeed)
+}
+
+// / @brief Open the window and run the frame loop until it is closed.
+// / @return error The error that ended Ebiten's run loop, if any.
+func runWindow() error {
+	contentW, contentH := contentSize()
+	ebiten.SetWindowSize(2*contentW, 2*contentH+barHeight)
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	titleSeed = runSeed
+	ebiten.SetWindowTitle(fmt.Sprintf("Wa-Tor - seed %d", runSeed))
+	return ebiten.RunGame(game{})
+}
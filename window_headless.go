@@ -0,0 +1,29 @@
+//go:build headless
+
+package main
+
+/// @file window_headless.go
+/// @brief Stand-ins for the window in binaries built with `-tags headless`.
+/// @details `go build -tags headless` leaves out every file that uses
+/// Ebiten (window.go, view.go, controls.go, ...), so the binary links no
+/// graphics library and runs on servers without a display. Such a binary
+/// always simulates headless, as if `-headless` were given; `-render
+/// ascii` and `tui`, `-break-at`, `-ensemble`, `-compare`, `bench` and the
+/// other windowless modes work as usual. The display flags and config
+/// fields below are still accepted, so the same scripts and scenario files
+/// work with both builds, but have no effect.
+
+import "errors"
+
+// / @brief Whether this binary can open a window.
+const haveWindow = false
+
+var fade bool = false
+var fadeFrames int = 4
+var showTiles bool = false
+var tintTiles bool = false
+
+// / @brief Never called: `main()` runs headless when `haveWindow` is false.
+func runWindow() error {
+	return errors.New("built with -tags headless: no window available")
+}
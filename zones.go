@@ -0,0 +1,32 @@
+package main
+
+/// @file zones.go
+/// @brief `-zones`: read the zones of the ocean from a JSON file.
+/// @details The file holds a JSON list of zones as described in
+/// wator/zones.go, e.g.
+///
+///	[{"name": "nursery", "x": 0, "y": 0, "w": 100, "h": 100, "fish_breed": 1}]
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/T0mmy380/Wa-Tor/wator"
+)
+
+// / @brief Read and install the zones from `path`.
+// / @return error Any error reading the file, or an invalid zone.
+func loadZones(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var list []wator.ZoneSpec
+	if err := decodeStrict(data, &list); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if err := wator.SetZones(list); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}